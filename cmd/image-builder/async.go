@@ -0,0 +1,392 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	adopipelines "github.com/kyma-project/test-infra/pkg/azuredevops/pipelines"
+	"github.com/kyma-project/test-infra/pkg/github/actions"
+	"github.com/kyma-project/test-infra/pkg/imagebuilder"
+	"github.com/kyma-project/test-infra/pkg/tags"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/pipelines"
+)
+
+// Enum of image-builder subcommands working with the ADO pipeline run started in async mode
+const (
+	// WaitCommand waits for the ADO pipeline run to finish and produces the build report.
+	WaitCommand = "wait"
+	// StatusCommand checks the ADO pipeline run status once and produces the build report if the run finished.
+	StatusCommand = "status"
+)
+
+// BuildStatusInProgress is the status of the build which is still running in the ADO pipeline.
+const BuildStatusInProgress = "inProgress"
+
+// cancelRunTimeout is the maximum time to wait for the ADO API to accept the pipeline run cancellation.
+const cancelRunTimeout = 30 * time.Second
+
+// defaultLogStreamInterval is the interval of polling the ADO pipeline run logs, when the refresh interval is not configured.
+const defaultLogStreamInterval = 10 * time.Second
+
+// RunHandle identifies the ADO pipeline run started by image-builder in async mode.
+// It holds all data required to resume polling the run status from another image-builder process.
+type RunHandle struct {
+	// Organization is the URL of the ADO organization running the pipeline
+	Organization string `json:"organization"`
+	// Project is the name of the ADO project running the pipeline
+	Project string `json:"project"`
+	// PipelineID is the ID of the ADO pipeline
+	PipelineID int `json:"pipeline_id"`
+	// RunID is the ID of the ADO pipeline run
+	RunID int `json:"run_id"`
+	// ImageName is the name of the image built by the run
+	ImageName string `json:"image_name"`
+	// Tags is a list of tags requested for the image
+	Tags []tags.Tag `json:"tags"`
+	// Registries is a list of registries chosen for the image, see Config.targetRegistries
+	Registries []string `json:"registries,omitempty"`
+}
+
+// newRunHandle creates the run handle for the ADO pipeline run started for the image.
+func newRunHandle(o options, runID int) RunHandle {
+	return RunHandle{
+		Organization: o.AdoConfig.ADOOrganizationURL,
+		Project:      o.AdoConfig.ADOProjectName,
+		PipelineID:   o.AdoConfig.ADOPipelineID,
+		RunID:        runID,
+		ImageName:    o.name,
+		Tags:         o.tags,
+		Registries:   o.targetRegistries(o.gitState),
+	}
+}
+
+// adoConfig returns the ADO configuration pointing to the pipeline run identified by the handle.
+// Values not stored in the handle, like retry strategy and refresh interval, are taken from the base configuration.
+func (h RunHandle) adoConfig(base adopipelines.Config) adopipelines.Config {
+	base.ADOOrganizationURL = h.Organization
+	base.ADOProjectName = h.Project
+	base.ADOPipelineID = h.PipelineID
+	return base
+}
+
+// WriteRunHandle writes the run handle as JSON to the file.
+func WriteRunHandle(handle RunHandle, path string) error {
+	data, err := json.Marshal(handle)
+	if err != nil {
+		return fmt.Errorf("failed to marshal run handle: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write run handle to file: %w", err)
+	}
+	return nil
+}
+
+// ReadRunHandle reads the run handle from the JSON file.
+func ReadRunHandle(path string) (RunHandle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return RunHandle{}, fmt.Errorf("failed to read run handle file: %w", err)
+	}
+	var handle RunHandle
+	if err := json.Unmarshal(data, &handle); err != nil {
+		return RunHandle{}, fmt.Errorf("failed to parse run handle: %w", err)
+	}
+	if handle.Organization == "" || handle.Project == "" || handle.RunID == 0 {
+		return RunHandle{}, fmt.Errorf("run handle is incomplete, organization, project and run ID are required")
+	}
+	return handle, nil
+}
+
+// startAsyncADORun writes the handle of the started ADO pipeline run and returns without waiting for the run to finish.
+// The handle is written to the file provided with the --run-handle-file flag and set as the run-handle GitHub output.
+func startAsyncADORun(o options, handle RunHandle) (*BuildResult, error) {
+	fmt.Printf("ADO pipeline run %d started in async mode, use the %s or %s command to get the result.\n", handle.RunID, WaitCommand, StatusCommand)
+
+	if o.runHandlePath != "" {
+		if err := WriteRunHandle(handle, o.runHandlePath); err != nil {
+			return nil, fmt.Errorf("build in ADO failed, %w", err)
+		}
+		fmt.Printf("Run handle written to %s\n", o.runHandlePath)
+	}
+
+	if o.ciSystem == GithubActions {
+		handleJSON, err := json.Marshal(handle)
+		if err != nil {
+			return nil, fmt.Errorf("cannot marshal run handle: %w", err)
+		}
+		if err := actions.SetOutput("run-handle", string(handleJSON)); err != nil {
+			return nil, fmt.Errorf("cannot set run-handle GitHub output: %w", err)
+		}
+	}
+
+	return &BuildResult{Status: BuildStatusInProgress}, nil
+}
+
+// waitForADORun waits for the ADO pipeline run identified by the handle to finish.
+// While waiting, logs of the run steps are streamed to stdout, unless running in silent mode.
+// It parses the build report from the complete run logs.
+// If the context is cancelled, e.g. image-builder is interrupted, or the build timeout from the ADO configuration is exceeded,
+// the ADO pipeline run is cancelled, so it doesn't keep building and pushing images.
+func waitForADORun(ctx context.Context, o options, adoClient adopipelines.Client, provider adopipelines.TokenProvider, handle RunHandle) (*BuildResult, error) {
+	adoConfig := handle.adoConfig(o.AdoConfig.GetADOConfig())
+
+	waitCtx := ctx
+	if adoConfig.ADOTimeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, adoConfig.ADOTimeout)
+		defer cancel()
+	}
+
+	var stream *logStream
+	if !o.silent {
+		stream = startLogStreaming(waitCtx, o, provider, handle)
+	}
+
+	// Fetch the ADO pipeline run result.
+	// GetRunResult function waits for the pipeline runs to finish and returns the result.
+	pipelineRunResult, err := adopipelines.GetRunResult(waitCtx, adoClient, adoConfig, &handle.RunID)
+	logs := stream.stop(waitCtx)
+	if err != nil {
+		if waitCtx.Err() != nil {
+			cancelADORun(o, provider, handle, cancelReason(waitCtx.Err(), adoConfig.ADOTimeout))
+		}
+		return nil, fmt.Errorf("build in ADO failed, failed getting ADO pipeline run result, err: %s", err)
+	}
+	fmt.Printf("ADO pipeline run finished with status: %s\n", *pipelineRunResult)
+
+	return getADORunReport(ctx, o, provider, handle, *pipelineRunResult, logs)
+}
+
+// cancelReason returns a human-readable reason of stopping to wait for the ADO pipeline run.
+func cancelReason(err error, timeout time.Duration) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Sprintf("build timeout of %s exceeded", timeout)
+	}
+	return "image-builder interrupted"
+}
+
+// cancelADORun cancels the ADO pipeline run identified by the handle.
+// The context used to wait for the run is already done at this point, so the cancel request uses its own context.
+// Failing to cancel the run is only logged, because image-builder is stopping anyway.
+func cancelADORun(o options, provider adopipelines.TokenProvider, handle RunHandle, reason string) {
+	logger := o.logger.With(
+		"runID", handle.RunID,
+		"organization", handle.Organization,
+		"project", handle.Project,
+		"pipelineID", handle.PipelineID,
+		"image", handle.ImageName,
+		"reason", reason,
+	)
+	logger.Warnw("Cancelling ADO pipeline run")
+
+	ctx, cancel := context.WithTimeout(context.Background(), cancelRunTimeout)
+	defer cancel()
+
+	adoConfig := handle.adoConfig(o.AdoConfig.GetADOConfig())
+	buildClient, err := adopipelines.NewBuildClientWithSP(ctx, adoConfig.ADOOrganizationURL, provider)
+	if err != nil {
+		logger.Errorw("Failed to cancel ADO pipeline run, failed creating ADO build client", "error", err)
+		return
+	}
+	if err := adopipelines.CancelRun(ctx, buildClient, adoConfig, &handle.RunID); err != nil {
+		logger.Errorw("Failed to cancel ADO pipeline run", "error", err)
+		return
+	}
+	fmt.Printf("ADO pipeline run %d for image %s cancelled, reason: %s\n", handle.RunID, handle.ImageName, reason)
+}
+
+// logStream streams logs of the ADO pipeline run in the background until it's stopped.
+type logStream struct {
+	streamer *adopipelines.LogStreamer
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// startLogStreaming starts streaming logs of the ADO pipeline run to the build output.
+// Logs are polled with the same interval as the run status.
+// Streaming is best effort, it's not started if the ADO build client can't be created and polling errors are only printed.
+func startLogStreaming(ctx context.Context, o options, provider adopipelines.TokenProvider, handle RunHandle) *logStream {
+	adoConfig := handle.adoConfig(o.AdoConfig.GetADOConfig())
+	buildClient, err := adopipelines.NewBuildClientWithSP(ctx, adoConfig.ADOOrganizationURL, provider)
+	if err != nil {
+		fmt.Printf("Can't stream ADO pipeline run logs, failed creating ADO build client, err: %s\n", err)
+		return nil
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream := &logStream{
+		streamer: adopipelines.NewLogStreamer(buildClient, adoConfig, &handle.RunID, o.buildOutput()),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	fmt.Println("Streaming ADO pipeline run logs.")
+	go func() {
+		defer close(stream.done)
+		interval := adoConfig.ADORefreshInterval
+		if interval <= 0 {
+			interval = defaultLogStreamInterval
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-streamCtx.Done():
+				return
+			case <-ticker.C:
+				if err := stream.streamer.Poll(streamCtx); err != nil && streamCtx.Err() == nil {
+					fmt.Printf("Failed streaming ADO pipeline run logs, err: %s\n", err)
+				}
+			}
+		}
+	}()
+	return stream
+}
+
+// stop stops streaming, prints the remaining log lines and returns the complete logs of the run.
+// It returns an empty string if logs weren't streamed or the remaining lines can't be fetched.
+func (s *logStream) stop(ctx context.Context) string {
+	if s == nil {
+		return ""
+	}
+	s.cancel()
+	<-s.done
+	if ctx.Err() != nil {
+		return ""
+	}
+	if err := s.streamer.Poll(ctx); err != nil {
+		fmt.Printf("Failed fetching remaining ADO pipeline run logs, err: %s\n", err)
+		return ""
+	}
+	return s.streamer.Logs()
+}
+
+// fetchADORunLogs fetches the logs of the finished ADO pipeline run and prints them to out.
+// It returns an empty string if the logs can't be fetched.
+func fetchADORunLogs(ctx context.Context, out io.Writer, provider adopipelines.TokenProvider, adoConfig adopipelines.Config, handle RunHandle) string {
+	fmt.Println("Getting ADO pipeline run logs.")
+	adoBuildClient, err := adopipelines.NewBuildClientWithSP(ctx, adoConfig.ADOOrganizationURL, provider)
+	if err != nil {
+		fmt.Printf("Can't read ADO pipeline run logs, failed creating ADO build client, err: %s", err)
+		return ""
+	}
+	logs, err := adopipelines.GetRunLogsWithBearerToken(ctx, adoBuildClient, &http.Client{}, adoConfig, &handle.RunID, provider)
+	if err != nil {
+		fmt.Printf("Failed read ADO pipeline run logs, err: %s", err)
+		return ""
+	}
+	fmt.Fprintf(out, "ADO pipeline image build logs:\n%s", logs)
+	return logs
+}
+
+// persistADORunLogs downloads logs of all steps of the finished ADO pipeline run into the logs directory.
+// Logs are artifacts for troubleshooting, so failing to persist them, including a missing or unwritable logs directory,
+// is only logged as a warning and doesn't fail the build.
+func persistADORunLogs(ctx context.Context, logger Logger, provider adopipelines.TokenProvider, adoConfig adopipelines.Config, handle RunHandle, logDir string) {
+	dir := filepath.Join(logDir, fmt.Sprintf("%s-%d", handle.ImageName, handle.RunID))
+	logger = logger.With("dir", dir, "runID", handle.RunID, "image", handle.ImageName)
+	// Check the logs directory can be created before downloading the logs from ADO.
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		logger.Warnw("Can't save ADO pipeline run logs, failed creating logs directory", "error", err)
+		return
+	}
+	fmt.Printf("Saving ADO pipeline run logs to %s\n", dir)
+	adoBuildClient, err := adopipelines.NewBuildClientWithSP(ctx, adoConfig.ADOOrganizationURL, provider)
+	if err != nil {
+		logger.Warnw("Can't save ADO pipeline run logs, failed creating ADO build client", "error", err)
+		return
+	}
+	index, err := adopipelines.DownloadRunLogs(ctx, adoBuildClient, adoConfig, &handle.RunID, dir)
+	if err != nil {
+		logger.Warnw("Failed saving ADO pipeline run logs", "error", err)
+		return
+	}
+	fmt.Printf("Saved logs of %d ADO pipeline run steps, index: %s\n", len(index.Steps), filepath.Join(dir, adopipelines.RunLogsIndexFile))
+}
+
+// getADORunReport parses the build report from the logs of the finished ADO pipeline run.
+// If the logs were not streamed while waiting for the run, they are fetched first.
+func getADORunReport(ctx context.Context, o options, provider adopipelines.TokenProvider, handle RunHandle, pipelineRunResult pipelines.RunResult, logs string) (*BuildResult, error) {
+	adoConfig := handle.adoConfig(o.AdoConfig.GetADOConfig())
+
+	if logs == "" {
+		logs = fetchADORunLogs(ctx, o.buildOutput(), provider, adoConfig, handle)
+	}
+
+	if o.logDir != "" {
+		persistADORunLogs(ctx, o.logger, provider, adoConfig, handle, o.logDir)
+	}
+
+	fmt.Println("Getting build report.")
+	// Parse the build report from the ADO pipeline run logs.
+	buildReport, err := imagebuilder.NewBuildReportFromLogs(logs)
+	if err != nil {
+		return nil, fmt.Errorf("build in ADO failed, failed parsing build report from ADO pipeline run logs, err: %s", err)
+	}
+
+	// The report is produced by the pipeline, so registries passed to it are recorded here
+	buildReport.Registries = handle.Registries
+	o.logger.Debugw("Parsed build report from ADO logs", "buildReport", buildReport)
+
+	return &BuildResult{
+		Status: string(pipelineRunResult),
+		Report: buildReport,
+	}, nil
+}
+
+// adoRunBackend resumes the ADO pipeline run started in async mode.
+// It implements BuildBackend, so the result of the resumed run is handled the same way as the result of a regular build.
+type adoRunBackend struct {
+	handle RunHandle
+	// wait defines if the backend waits for the run to finish or checks its status only once.
+	wait bool
+}
+
+// Build waits for the ADO pipeline run to finish or checks its status, depending on the command.
+// If the run is still in progress when checking the status, the result has in progress status and no report.
+func (b *adoRunBackend) Build(ctx context.Context, o options) (*BuildResult, error) {
+	fmt.Printf("Resuming ADO pipeline run %d for image %s.\n", b.handle.RunID, b.handle.ImageName)
+	if err := loadAzureCredentials(&o); err != nil {
+		return nil, err
+	}
+
+	adoClient, provider, err := newADOClient(ctx, o, b.handle.Organization)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.wait {
+		return waitForADORun(ctx, o, adoClient, provider, b.handle)
+	}
+
+	run, err := adopipelines.GetRun(ctx, adoClient, b.handle.adoConfig(o.AdoConfig.GetADOConfig()), &b.handle.RunID)
+	if err != nil {
+		return nil, fmt.Errorf("failed getting ADO pipeline run status: %w", err)
+	}
+	if run.State == nil || *run.State != pipelines.RunStateValues.Completed {
+		fmt.Printf("ADO pipeline run %d is still in progress.\n", b.handle.RunID)
+		return &BuildResult{Status: BuildStatusInProgress}, nil
+	}
+
+	fmt.Printf("ADO pipeline run finished with status: %s\n", *run.Result)
+	return getADORunReport(ctx, o, provider, b.handle, *run.Result, "")
+}
+
+// runHandleCommand runs the wait or status command for the ADO pipeline run identified by the handle file.
+func runHandleCommand(ctx context.Context, o options) error {
+	handle, err := ReadRunHandle(o.runHandlePath)
+	if err != nil {
+		return err
+	}
+	o.name = handle.ImageName
+	o.tags = handle.Tags
+
+	backend := &adoRunBackend{handle: handle, wait: o.command == WaitCommand}
+	return runBuild(ctx, o, backend)
+}
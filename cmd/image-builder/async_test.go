@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/test-infra/pkg/azuredevops/pipelines"
+	"github.com/kyma-project/test-infra/pkg/imagebuilder"
+	"github.com/kyma-project/test-infra/pkg/sets"
+	"github.com/kyma-project/test-infra/pkg/tags"
+	adoPipelines "github.com/microsoft/azure-devops-go-api/azuredevops/v7/pipelines"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRunHandle(t *testing.T) {
+	o := options{
+		Config: Config{
+			AdoConfig: pipelines.Config{
+				ADOOrganizationURL: "https://dev.azure.com/org",
+				ADOProjectName:     "project",
+				ADOPipelineID:      123,
+			},
+			Registry:    Registry{"europe-docker.pkg.dev/kyma-project/prod"},
+			DevRegistry: Registry{"europe-docker.pkg.dev/kyma-project/dev"},
+		},
+		name:     "test-image",
+		tags:     sets.Tags{{Name: "latest", Value: "latest"}},
+		gitState: GitStateConfig{JobType: "presubmit", isPullRequest: true},
+	}
+	expected := RunHandle{
+		Organization: "https://dev.azure.com/org",
+		Project:      "project",
+		PipelineID:   123,
+		RunID:        42,
+		ImageName:    "test-image",
+		Tags:         []tags.Tag{{Name: "latest", Value: "latest"}},
+		Registries:   []string{"europe-docker.pkg.dev/kyma-project/dev"},
+	}
+
+	handle := newRunHandle(o, 42)
+	if !reflect.DeepEqual(handle, expected) {
+		t.Errorf("newRunHandle(): got %+v, want %+v", handle, expected)
+	}
+
+	path := filepath.Join(t.TempDir(), "handle.json")
+	if err := WriteRunHandle(handle, path); err != nil {
+		t.Fatalf("WriteRunHandle(): got unexpected error: %s", err)
+	}
+	got, err := ReadRunHandle(path)
+	if err != nil {
+		t.Fatalf("ReadRunHandle(): got unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ReadRunHandle(): got %+v, want %+v", got, expected)
+	}
+
+	adoConfig := got.adoConfig(pipelines.Config{ADOOrganizationURL: "https://other", ADORefreshInterval: 30})
+	if adoConfig.ADOOrganizationURL != "https://dev.azure.com/org" || adoConfig.ADOProjectName != "project" || adoConfig.ADOPipelineID != 123 || adoConfig.ADORefreshInterval != 30 {
+		t.Errorf("adoConfig(): unexpected config %+v", adoConfig)
+	}
+}
+
+func TestReadRunHandle_incomplete(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "handle.json")
+	if err := os.WriteFile(path, []byte(`{"organization":"https://dev.azure.com/org"}`), 0644); err != nil {
+		t.Fatalf("failed writing handle file: %s", err)
+	}
+
+	_, err := ReadRunHandle(path)
+	if err == nil {
+		t.Error("error expected, but no one occured")
+	}
+}
+
+func Test_startAsyncADORun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "handle.json")
+	handle := RunHandle{Organization: "https://dev.azure.com/org", Project: "project", PipelineID: 123, RunID: 42, ImageName: "test-image"}
+
+	result, err := startAsyncADORun(options{runHandlePath: path}, handle)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err)
+	}
+	if result.Status != BuildStatusInProgress || result.Report != nil {
+		t.Errorf("startAsyncADORun(): unexpected result %+v", result)
+	}
+	if result.Failed() {
+		t.Error("async run result must not be reported as failed")
+	}
+	if _, err := ReadRunHandle(path); err != nil {
+		t.Errorf("run handle file not written: %s", err)
+	}
+}
+
+func Test_cancelReason(t *testing.T) {
+	tc := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "timeout exceeded",
+			err:      context.DeadlineExceeded,
+			expected: "build timeout of 1h0m0s exceeded",
+		},
+		{
+			name:     "interrupted",
+			err:      context.Canceled,
+			expected: "image-builder interrupted",
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			got := cancelReason(c.err, time.Hour)
+			if got != c.expected {
+				t.Errorf("cancelReason(): got %s, want %s", got, c.expected)
+			}
+		})
+	}
+}
+
+func Test_getADORunReport(t *testing.T) {
+	logs := `2025-04-27T10:41:56.4260338Z ---IMAGE BUILD REPORT---
+2025-04-27T10:41:56.4342736Z {"status": "Succeeded", "pushed": true, "image_name": "test-image", "tags": ["PR-5"]}
+2025-04-27T10:41:56.4359897Z ---END OF IMAGE BUILD REPORT---`
+	tc := []struct {
+		name           string
+		result         adoPipelines.RunResult
+		expectedFailed bool
+	}{
+		{name: "succeeded run", result: adoPipelines.RunResultValues.Succeeded},
+		{name: "failed run", result: adoPipelines.RunResultValues.Failed, expectedFailed: true},
+		{name: "run canceled outside of image-builder", result: adoPipelines.RunResultValues.Canceled, expectedFailed: true},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			o := options{logger: zap.NewNop().Sugar()}
+			handle := RunHandle{RunID: 42, ImageName: "test-image", Registries: []string{"dev-reg"}}
+
+			result, err := getADORunReport(context.Background(), o, nil, handle, c.result, logs)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err)
+			}
+			if result.Status != string(c.result) {
+				t.Errorf("status: got %s, want %s", result.Status, c.result)
+			}
+			if result.Failed() != c.expectedFailed {
+				t.Errorf("Failed(): got %t, want %t", result.Failed(), c.expectedFailed)
+			}
+		})
+	}
+}
+
+func Test_runBuild_status_output(t *testing.T) {
+	tc := []struct {
+		name     string
+		result   *BuildResult
+		expected []string
+	}{
+		{
+			name:     "run still in progress",
+			result:   &BuildResult{Status: BuildStatusInProgress},
+			expected: []string{"status=inProgress"},
+		},
+		{
+			name: "run finished",
+			result: &BuildResult{
+				Status: BuildStatusSucceeded,
+				Report: &imagebuilder.BuildReport{Status: "Succeeded", Name: "test-image", Images: []string{"reg/test-image:v1"}},
+			},
+			expected: []string{"status=succeeded", "adoResult=succeeded"},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			outputFile := filepath.Join(t.TempDir(), "github_output")
+			if err := os.WriteFile(outputFile, nil, 0644); err != nil {
+				t.Fatalf("failed creating output file: %v", err)
+			}
+			t.Setenv("GITHUB_OUTPUT", outputFile)
+			backend := &fakeBackend{results: map[string]*BuildResult{"test-image": c.result}}
+			o := options{logger: zap.NewNop().Sugar(), ciSystem: GithubActions, name: "test-image"}
+
+			if err := runBuild(context.Background(), o, backend); err != nil {
+				t.Fatalf("got unexpected error: %s", err)
+			}
+			got, err := os.ReadFile(outputFile)
+			if err != nil {
+				t.Fatalf("failed reading output file: %v", err)
+			}
+			outputs := strings.Split(strings.TrimSpace(string(got)), "\n")
+			for _, output := range c.expected {
+				if !slices.Contains(outputs, output) {
+					t.Errorf("GitHub outputs %q don't contain %q", outputs, output)
+				}
+			}
+		})
+	}
+}
+
+func Test_persistADORunLogs_unwritable_logDir(t *testing.T) {
+	// A file in place of the logs directory makes creating the run logs directory fail.
+	logDir := filepath.Join(t.TempDir(), "artifacts")
+	if err := os.WriteFile(logDir, nil, 0644); err != nil {
+		t.Fatalf("failed creating file: %v", err)
+	}
+	core, logs := observer.New(zapcore.DebugLevel)
+	handle := RunHandle{RunID: 42, ImageName: "test-image"}
+
+	persistADORunLogs(context.Background(), zap.New(core).Sugar(), nil, pipelines.Config{}, handle, logDir)
+
+	warnings := logs.FilterLevelExact(zapcore.WarnLevel).All()
+	if len(warnings) != 1 {
+		t.Fatalf("expected one warning, got %d: %v", len(warnings), logs.All())
+	}
+	if dir := warnings[0].ContextMap()["dir"]; dir != filepath.Join(logDir, "test-image-42") {
+		t.Errorf("expected warning for logs directory %s, got %v", filepath.Join(logDir, "test-image-42"), dir)
+	}
+}
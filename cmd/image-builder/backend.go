@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kyma-project/test-infra/pkg/github/actions"
+	"github.com/kyma-project/test-infra/pkg/imagebuilder"
+)
+
+// Enum of supported build backends
+const (
+	// ADOBackend triggers the oci-image-builder pipeline in Azure DevOps to build the image.
+	ADOBackend = "ado"
+	// LocalBackend builds the image on the local machine using docker buildx.
+	LocalBackend = "local"
+)
+
+// Enum of build statuses reported by build backends.
+// Values match ADO pipeline run results, so the adoResult output stays the same for every backend.
+const (
+	BuildStatusSucceeded = "succeeded"
+	BuildStatusFailed    = "failed"
+	BuildStatusUnknown   = "unknown"
+	// BuildStatusCanceled is the status of the ADO pipeline run canceled before it finished, e.g. from the ADO UI.
+	BuildStatusCanceled = "canceled"
+)
+
+// BuildBackend builds an image from the options provided by the user.
+// Each implementation is responsible for building and pushing the image
+// and for producing the imagebuilder.BuildReport describing the result.
+type BuildBackend interface {
+	// Build builds the image and returns the build result.
+	// An error is returned when the build could not be run at all.
+	// A build that ran but failed is reported through the BuildResult status.
+	Build(ctx context.Context, o options) (*BuildResult, error)
+}
+
+// BuildResult holds the outcome of a build run by a BuildBackend.
+type BuildResult struct {
+	// Status is the final status of the build, one of BuildStatusSucceeded, BuildStatusFailed, BuildStatusUnknown or BuildStatusCanceled.
+	Status string
+	// Report is the build report produced by the backend.
+	// It's nil when the backend didn't build an image, for example in the ADO preview mode.
+	Report *imagebuilder.BuildReport
+}
+
+// Failed returns true if the build didn't finish successfully.
+// A canceled build didn't push the image, so it's failed too.
+func (r *BuildResult) Failed() bool {
+	return r.Status == BuildStatusFailed || r.Status == BuildStatusUnknown || r.Status == BuildStatusCanceled
+}
+
+// getBuildBackendName returns the name of the build backend to use.
+// The --backend flag takes precedence over the build-backend field from the config file.
+// If none is set, the ADO backend is used.
+func getBuildBackendName(o options) string {
+	if o.backend != "" {
+		return o.backend
+	}
+	if o.BuildBackend != "" {
+		return o.BuildBackend
+	}
+	return ADOBackend
+}
+
+// newBuildBackend returns the BuildBackend selected by the user.
+func newBuildBackend(o options) (BuildBackend, error) {
+	switch name := getBuildBackendName(o); name {
+	case ADOBackend:
+		return &adoBackend{}, nil
+	case LocalBackend:
+		return newLocalBackend(), nil
+	default:
+		return nil, fmt.Errorf("unknown build backend %q, supported backends: %s, %s", name, ADOBackend, LocalBackend)
+	}
+}
+
+// adoBackend builds images by triggering the oci-image-builder pipeline in Azure DevOps.
+type adoBackend struct{}
+
+// Build builds the image in the ADO pipeline.
+func (b *adoBackend) Build(ctx context.Context, o options) (*BuildResult, error) {
+	return buildInADO(ctx, o)
+}
+
+// runBuild builds the image with the given backend and handles the build result.
+// It sets the GitHub outputs when running in GitHub Actions and writes the build report to the file if requested.
+// If the build is still in progress, only the status GitHub output is set, so workflows can tell it from the finished build.
+// It returns an error if the build failed.
+func runBuild(ctx context.Context, o options, backend BuildBackend) error {
+	result, err := backend.Build(ctx, o)
+	if err != nil {
+		return err
+	}
+
+	if result.Status == BuildStatusInProgress {
+		// The build is still running, workflows read the status output to tell it from the finished build.
+		if o.ciSystem == GithubActions {
+			if err := actions.SetOutput("status", result.Status); err != nil {
+				return fmt.Errorf("cannot set status GitHub output: %w", err)
+			}
+		}
+		return nil
+	}
+
+	if result.Report == nil {
+		// Backend didn't build any image, for example preview run, nothing to report.
+		return nil
+	}
+
+	// if run in github actions, set output parameters
+	if o.ciSystem == GithubActions {
+		err = setGithubBuildOutputs(o, result)
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.buildReportPath != "" {
+		err = imagebuilder.WriteReportToFile(result.Report, o.buildReportPath)
+		if err != nil {
+			return fmt.Errorf("failed writing build report to file: %w", err)
+		}
+	}
+
+	if result.Failed() {
+		return fmt.Errorf("build finished with status: %s", result.Status)
+	}
+	return nil
+}
+
+// setGithubBuildOutputs sets GitHub Actions outputs with data from the build result.
+func setGithubBuildOutputs(o options, result *BuildResult) error {
+	fmt.Println("Setting GitHub outputs.")
+	buildReport := result.Report
+
+	o.logger.Debugw("Extracted built images from build report", "images", buildReport.Images, "architectures", buildReport.Architectures)
+
+	imagesJSON, err := json.Marshal(buildReport.Images)
+	if err != nil {
+		return fmt.Errorf("cannot marshal list of images: %w", err)
+	}
+
+	architecturesJSON, err := json.Marshal(buildReport.Architectures)
+	if err != nil {
+		return fmt.Errorf("cannot marshal list of architectures: %w", err)
+	}
+
+	o.logger.Debugw("Set GitHub outputs", "images", string(imagesJSON), "architectures", string(architecturesJSON), "digest", buildReport.Digest, "adoResult", result.Status)
+
+	err = actions.SetOutput("images", string(imagesJSON))
+	if err != nil {
+		return fmt.Errorf("cannot set images GitHub output: %w", err)
+	}
+
+	if err := actions.SetOutput("architectures", string(architecturesJSON)); err != nil {
+		return fmt.Errorf("cannot set architectures GitHub output: %w", err)
+	}
+
+	if err := actions.SetOutput("digest", buildReport.Digest); err != nil {
+		return fmt.Errorf("cannot set digest GitHub output: %w", err)
+	}
+
+	// Output full build report as JSON
+	buildReportJSON, err := json.Marshal(buildReport)
+	if err != nil {
+		return fmt.Errorf("cannot marshal build report: %w", err)
+	}
+
+	if err := actions.SetOutput("build-report", string(buildReportJSON)); err != nil {
+		return fmt.Errorf("cannot set build-report GitHub output: %w", err)
+	}
+
+	if err := actions.SetOutput("status", result.Status); err != nil {
+		return fmt.Errorf("cannot set status GitHub output: %w", err)
+	}
+
+	// The output name is kept for backward compatibility with existing workflows, it holds the status for any backend.
+	err = actions.SetOutput("adoResult", result.Status)
+	if err != nil {
+		return fmt.Errorf("cannot set adoResult GitHub output: %w", err)
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/google/go-github/v90/github"
 	adoPipelines "github.com/kyma-project/test-infra/pkg/azuredevops/pipelines"
@@ -22,15 +23,34 @@ const (
 	GithubActions CISystem = "GithubActions"
 	AzureDevOps   CISystem = "AzureDevOps"
 	Jenkins       CISystem = "Jenkins"
+	GitLab        CISystem = "GitLab"
 )
 
+// Types of git refs the job can run for
+const (
+	RefTypeBranch = "branch"
+	RefTypeTag    = "tag"
+)
+
+// tagRefPrefix is the prefix of full names of git tag refs
+const tagRefPrefix = "refs/tags/"
+
+// defaultTagPushTag is the default tag of images built for git tags, used if default-tag-push-tag is not configured.
+// Images are tagged with the git tag name, which must be a semantic version, optionally prefixed with "v".
+// Build metadata is not allowed, because "+" is not a valid character of image tags.
+var defaultTagPushTag = tags.Tag{
+	Name:       "default_tag",
+	Value:      "{{ .GitTag }}",
+	Validation: `^v?(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(-[0-9A-Za-z.-]+)?$`,
+}
+
 type Config struct {
 	AdoConfig adoPipelines.Config `yaml:"ado-config,omitempty" json:"ado-config,omitempty"`
 	// Registry is URL where clean build should land.
 	Registry Registry `yaml:"registry" json:"registry"`
 	// DevRegistry is Registry URL where development/dirty images should land.
+	// Images built for pull requests and merge groups are pushed to it, see targetRegistries.
 	// If not set then the Registry field is used.
-	// This field is only valid when running in CI (CI env variable is set to `true`)
 	DevRegistry Registry `yaml:"dev-registry" json:"dev-registry"`
 	// Cache options that are directly related to docker buildx flags
 	Cache CacheConfig `yaml:"cache" json:"cache"`
@@ -46,10 +66,22 @@ type Config struct {
 	// The value can be a go-template string or literal tag value string.
 	// See tags.Tag struct for more information and available fields
 	DefaultMergeGroupTag tags.Tag `yaml:"default-merge-group-tag" json:"default-merge-group-tag"`
+	// Default Tag template used for images build on git tag push or release.
+	// The value can be a go-template string or literal tag value string.
+	// If not set, images are tagged with the git tag name, which must be a semantic version.
+	// See tags.Tag struct for more information and available fields
+	DefaultTagPushTag tags.Tag `yaml:"default-tag-push-tag,omitempty" json:"default-tag-push-tag,omitempty"`
 	// AdditionalPRTag is an extra tag added for pull request builds alongside DefaultPRTag.
 	// The value can be a go-template string or literal tag value string.
 	// See tags.Tag struct for more information and available fields
 	AdditionalPRTag tags.Tag `yaml:"additional-pr-tag" json:"additional-pr-tag"`
+	// TagPolicies defines tags of images per job type and, optionally, per git ref.
+	// Supported keys are presubmit, postsubmit, merge_group, schedule, workflow_dispatch and tag.
+	// Job types not defined here use the default tag fields above.
+	TagPolicies TagPolicies `yaml:"tag-policies,omitempty" json:"tag-policies,omitempty"`
+	// TagEnv defines environment variables which tag templates can read with {{ .Env "NAME" }}.
+	// Reading variables not allowed here fails parsing tags.
+	TagEnv TagEnvConfig `yaml:"tag-env,omitempty" json:"tag-env,omitempty"`
 	// LogFormat defines the format docker buildx logs are projected.
 	// Supported formats are 'color', 'text' and 'json'. Default: 'color'
 	LogFormat string `yaml:"log-format" json:"log-format"`
@@ -58,6 +90,12 @@ type Config struct {
 	// SignConfig contains custom configuration of signers
 	// as well as org/repo mapping of enabled signers in specific repository
 	SignConfig SignConfig `yaml:"sign-config" json:"sign-config"`
+	// BuildBackend is the name of the backend used to build images.
+	// Supported backends are 'ado' and 'local'. Default: 'ado'
+	BuildBackend string `yaml:"build-backend,omitempty" json:"build-backend,omitempty"`
+	// GenericCI maps values provided by a CI system without a built-in git state loader to the git state.
+	// It's used only when none of the built-in CI systems is detected.
+	GenericCI *GenericCIConfig `yaml:"generic-ci,omitempty" json:"generic-ci,omitempty"`
 }
 
 type SignConfig struct {
@@ -66,6 +104,23 @@ type SignConfig struct {
 	EnabledSigners map[string][]string `yaml:"enabled-signers" json:"enabled-signers"`
 	// Signers contains configuration for multiple signing backends, which can be used to sign resulting image
 	Signers []sign.SignerConfig `yaml:"signers" json:"signers"`
+	// RequiredSigners contains org/repo mapping of signers whose signatures are required in the verify-only mode
+	// Use * to require signer for all repositories. If it's empty, enabled signers are required
+	RequiredSigners map[string][]string `yaml:"required-signers,omitempty" json:"required-signers,omitempty"`
+}
+
+// TagEnvConfig controls access of tag templates to environment variables.
+// Both lists contain glob patterns of variable names, e.g. GITHUB_* or BUILD_VERSION.
+type TagEnvConfig struct {
+	// Allow lists variables which tag templates can read. By default, no variables can be read.
+	Allow tags.EnvPatterns `yaml:"allow,omitempty" json:"allow,omitempty"`
+	// Sensitive lists variables whose values are never logged. They must also be allowed to be read.
+	Sensitive tags.EnvPatterns `yaml:"sensitive,omitempty" json:"sensitive,omitempty"`
+}
+
+// taggerOptions returns Tagger options applying the environment variables access.
+func (c TagEnvConfig) taggerOptions() []tags.TagOption {
+	return []tags.TagOption{tags.EnvAllowlist(c.Allow...), tags.SensitiveEnv(c.Sensitive...)}
 }
 
 type CacheConfig struct {
@@ -79,6 +134,16 @@ type CacheConfig struct {
 	CacheRepo string `yaml:"cache-repo" json:"cache-repo"`
 }
 
+// targetRegistries returns registries the image built for the git state is pushed to.
+// Pull request and merge group builds are pushed to DevRegistry, other builds, e.g. postsubmits and releases, to Registry.
+// If DevRegistry is not set, Registry is used for all builds.
+func (c Config) targetRegistries(gitState GitStateConfig) Registry {
+	if gitState.isDevBuild() && len(c.DevRegistry) > 0 {
+		return c.DevRegistry
+	}
+	return c.Registry
+}
+
 // ParseConfig parses yaml configuration into Config
 func (c *Config) ParseConfig(f []byte) error {
 	return yaml.Unmarshal(f, c)
@@ -121,6 +186,11 @@ type GitStateConfig struct {
 	BaseCommitRef string
 	// Commit SHA for head of the pull request
 	PullHeadCommitSHA string
+	// Type of the git ref the job runs for, allowed values "branch" or "tag".
+	// Empty if the CI system doesn't provide it.
+	RefType string
+	// Name of the git tag the job runs for, set only if RefType is "tag"
+	TagName string
 	// isPullRequest contains information whether event which triggered the job was from pull request
 	isPullRequest bool
 }
@@ -129,8 +199,36 @@ func (gitState GitStateConfig) IsPullRequest() bool {
 	return gitState.isPullRequest
 }
 
+// tagsGitState returns the git state passed to the tagger, so its values can be used in tag templates.
+func (gitState GitStateConfig) tagsGitState() tags.GitState {
+	state := tags.GitState{
+		RepositoryOwner:   gitState.RepositoryOwner,
+		RepositoryName:    gitState.RepositoryName,
+		JobType:           gitState.JobType,
+		BaseCommitSHA:     gitState.BaseCommitSHA,
+		BaseCommitRef:     gitState.BaseCommitRef,
+		PullHeadCommitSHA: gitState.PullHeadCommitSHA,
+	}
+	if gitState.IsTag() {
+		state.TagName = gitState.TagName
+	}
+	return state
+}
+
+// isDevBuild returns true if the image is built from code not merged yet, for a pull request or a merge group.
+func (gitState GitStateConfig) isDevBuild() bool {
+	return gitState.IsPullRequest() || gitState.JobType == "presubmit" || gitState.JobType == "merge_group"
+}
+
+// IsTag returns true if the job runs for a git tag, e.g. for a tag push or a release.
+func (gitState GitStateConfig) IsTag() bool {
+	return gitState.RefType == RefTypeTag && gitState.TagName != ""
+}
+
 // TODO (dekiel): Add logger parameter to all functions reading a git state.
-func LoadGitStateConfig(logger Logger, ciSystem CISystem) (GitStateConfig, error) {
+// Built-in CI systems read the git state from their predefined environment variables.
+// The generic CI system reads it using the mapping from the generic-ci config section.
+func LoadGitStateConfig(logger Logger, ciSystem CISystem, genericCI *GenericCIConfig) (GitStateConfig, error) {
 	switch ciSystem {
 	// Load from env specific for Azure DevOps
 	case AzureDevOps:
@@ -140,6 +238,15 @@ func LoadGitStateConfig(logger Logger, ciSystem CISystem) (GitStateConfig, error
 		return loadGithubActionsGitState()
 	case Jenkins:
 		return loadJenkinsGitState(logger)
+	// Load from env specific for GitLab CI
+	case GitLab:
+		return loadGitLabGitState()
+	// Load using the mapping from config
+	case Generic:
+		if genericCI == nil {
+			return GitStateConfig{}, fmt.Errorf("generic ci system requires generic-ci config")
+		}
+		return loadGenericGitState(logger, *genericCI)
 	default:
 		// Unknown CI System, return error and empty git state
 		return GitStateConfig{}, fmt.Errorf("unknown ci system, got %s", ciSystem)
@@ -211,13 +318,14 @@ func loadGithubActionsGitState() (GitStateConfig, error) {
 		return GitStateConfig{}, fmt.Errorf("the GITHUB_EVENT_PATH environment variable is not set. Please ensure the image-builder is running in GitHub environment")
 	}
 	// For PR and push events commit sha will be fetched from event payload
+	readsPayloadRef := eventName == "pull_request_target" || eventName == "pull_request" || eventName == "push"
 	commitSHA, present := os.LookupEnv("GITHUB_SHA")
-	if !present && (eventName != "pull_request_target" && eventName != "push") {
+	if !present && !readsPayloadRef {
 		return GitStateConfig{}, fmt.Errorf("the GITHUB_SHA environment variable is not set, it should be set to HEAD commit SHA. Please ensure the image-builder is running in GitHub environment")
 	}
 	// For PR and push events commit ref will be fetched from event payload
 	gitRef, present := os.LookupEnv("GITHUB_REF")
-	if !present && (eventName != "pull_request_target" && eventName != "push") {
+	if !present && !readsPayloadRef {
 		return GitStateConfig{}, fmt.Errorf("the GITHUB_REF environment variable is not set, it should be set to current ref. Please ensure the image-builder is running in GitHub environment")
 	}
 
@@ -229,13 +337,23 @@ func loadGithubActionsGitState() (GitStateConfig, error) {
 
 	// Handle different events types
 	switch eventName {
-	case "pull_request_target":
+	case "pull_request_target", "pull_request":
 		var payload github.PullRequestEvent
 		err = json.Unmarshal(data, &payload)
 		if err != nil {
 			return GitStateConfig{}, fmt.Errorf("failed to parse event payload: %s", err)
 		}
+		// pull_request events are triggered for fork pull requests too, but only pull requests from the same repository are built.
+		// Fork pull requests must use the pull_request_target event.
+		if eventName == "pull_request" {
+			head := payload.GetPullRequest().GetHead().GetRepo().GetFullName()
+			base := payload.GetPullRequest().GetBase().GetRepo().GetFullName()
+			if head != base {
+				return GitStateConfig{}, fmt.Errorf("pull_request event for pull request from fork %s is not supported, use the pull_request_target event for pull requests from forks", head)
+			}
+		}
 
+		// The ref of the pull request is its base branch, so ref scoped tag policies and conditions match the target branch
 		return GitStateConfig{
 			RepositoryName:    *payload.Repo.Name,
 			RepositoryOwner:   *payload.Repo.Owner.Login,
@@ -243,6 +361,8 @@ func loadGithubActionsGitState() (GitStateConfig, error) {
 			PullRequestNumber: *payload.Number,
 			BaseCommitSHA:     *payload.PullRequest.Base.SHA,
 			PullHeadCommitSHA: *payload.PullRequest.Head.SHA,
+			BaseCommitRef:     "refs/heads/" + payload.GetPullRequest().GetBase().GetRef(),
+			RefType:           RefTypeBranch,
 			isPullRequest:     true,
 		}, nil
 
@@ -252,11 +372,43 @@ func loadGithubActionsGitState() (GitStateConfig, error) {
 		if err != nil {
 			return GitStateConfig{}, fmt.Errorf("failed to parse event payload: %s", err)
 		}
-		return GitStateConfig{
+		// Deleting a branch or tag triggers push event without head commit
+		if payload.HeadCommit == nil {
+			return GitStateConfig{}, fmt.Errorf("push event payload has no head commit, image-builder can't build images for deleted refs")
+		}
+		gitState := GitStateConfig{
 			RepositoryName:  *payload.Repo.Name,
 			RepositoryOwner: *payload.Repo.Owner.Login,
 			JobType:         "postsubmit",
 			BaseCommitSHA:   *payload.HeadCommit.ID,
+			BaseCommitRef:   payload.GetRef(),
+			RefType:         RefTypeBranch,
+		}
+		if tagName, isTag := strings.CutPrefix(payload.GetRef(), tagRefPrefix); isTag {
+			gitState.RefType = RefTypeTag
+			gitState.TagName = tagName
+		}
+		return gitState, nil
+
+	case "release":
+		var payload github.ReleaseEvent
+		err = json.Unmarshal(data, &payload)
+		if err != nil {
+			return GitStateConfig{}, fmt.Errorf("failed to parse event payload: %s", err)
+		}
+		// Only publishing the release builds images, editing or deleting it must not rebuild and retag images
+		if action := payload.GetAction(); action != "published" && action != "released" {
+			return GitStateConfig{}, fmt.Errorf("release event with action %s is not supported, supported actions: published, released", action)
+		}
+		// The release is built as a postsubmit of its tag, GITHUB_SHA is the commit the tag points to
+		return GitStateConfig{
+			RepositoryName:  *payload.Repo.Name,
+			RepositoryOwner: *payload.Repo.Owner.Login,
+			JobType:         "postsubmit",
+			BaseCommitSHA:   commitSHA,
+			BaseCommitRef:   tagRefPrefix + payload.GetRelease().GetTagName(),
+			RefType:         RefTypeTag,
+			TagName:         payload.GetRelease().GetTagName(),
 		}, nil
 
 	case "workflow_dispatch":
@@ -374,6 +526,97 @@ func loadJenkinsGitState(logger Logger) (GitStateConfig, error) {
 	return gitState, nil
 }
 
+// loadGitLabGitState reads the git state from predefined GitLab CI variables.
+// Merge request pipelines are mapped to presubmit jobs, branch and tag pipelines to postsubmit jobs,
+// so tags and ADO template parameters work the same way as for other CI systems.
+// See: https://docs.gitlab.com/ee/ci/variables/predefined_variables.html
+func loadGitLabGitState() (GitStateConfig, error) {
+	repoName, present := os.LookupEnv("CI_PROJECT_NAME")
+	if !present {
+		return GitStateConfig{}, fmt.Errorf("CI_PROJECT_NAME environment variable is not set, please ensure the image-builder is running in GitLab CI")
+	}
+
+	// Namespace holds the group and subgroups the project belongs to
+	repoOwner, present := os.LookupEnv("CI_PROJECT_NAMESPACE")
+	if !present {
+		return GitStateConfig{}, fmt.Errorf("CI_PROJECT_NAMESPACE environment variable is not set, please ensure the image-builder is running in GitLab CI")
+	}
+
+	pipelineSource, present := os.LookupEnv("CI_PIPELINE_SOURCE")
+	if !present {
+		return GitStateConfig{}, fmt.Errorf("CI_PIPELINE_SOURCE environment variable is not set, please ensure the image-builder is running in GitLab CI")
+	}
+
+	commitSHA, present := os.LookupEnv("CI_COMMIT_SHA")
+	if !present {
+		return GitStateConfig{}, fmt.Errorf("CI_COMMIT_SHA environment variable is not set, please ensure the image-builder is running in GitLab CI")
+	}
+
+	gitState := GitStateConfig{
+		RepositoryName:  repoName,
+		RepositoryOwner: repoOwner,
+	}
+
+	if pipelineSource == "merge_request_event" {
+		mrIID, present := os.LookupEnv("CI_MERGE_REQUEST_IID")
+		if !present {
+			return GitStateConfig{}, fmt.Errorf("CI_MERGE_REQUEST_IID environment variable is not set, please set it to valid merge request number")
+		}
+		pullNumber, err := strconv.Atoi(mrIID)
+		if err != nil {
+			return GitStateConfig{}, fmt.Errorf("CI_MERGE_REQUEST_IID environment variable contains invalid value, please set it to correct integer merge request number: %w", err)
+		}
+
+		baseSHA, present := os.LookupEnv("CI_MERGE_REQUEST_DIFF_BASE_SHA")
+		if !present {
+			return GitStateConfig{}, fmt.Errorf("CI_MERGE_REQUEST_DIFF_BASE_SHA environment variable is not set, please set it to valid merge request base SHA")
+		}
+
+		// In merged results pipelines CI_COMMIT_SHA is the merge commit, the head of the merge request is in CI_MERGE_REQUEST_SOURCE_BRANCH_SHA
+		headSHA := commitSHA
+		if sourceSHA := os.Getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_SHA"); sourceSHA != "" {
+			headSHA = sourceSHA
+		}
+
+		gitState.JobType = "presubmit"
+		gitState.PullRequestNumber = pullNumber
+		gitState.BaseCommitSHA = baseSHA
+		// The ref has the same format as for push pipelines, so ref scoped tag policies and conditions match both
+		if branch := os.Getenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME"); branch != "" {
+			gitState.BaseCommitRef = "refs/heads/" + branch
+		}
+		gitState.PullHeadCommitSHA = headSHA
+		gitState.RefType = RefTypeBranch
+		gitState.isPullRequest = true
+
+		return gitState, nil
+	}
+
+	switch pipelineSource {
+	case "push":
+		gitState.JobType = "postsubmit"
+	case "schedule":
+		gitState.JobType = "schedule"
+	case "web", "api", "trigger":
+		// Pipelines started manually or through the API are on-demand runs
+		gitState.JobType = "workflow_dispatch"
+	default:
+		return GitStateConfig{}, fmt.Errorf("CI_PIPELINE_SOURCE environment variable is set to unsupported value \"%s\", image-builder supports merge_request_event, push, schedule, web, api and trigger pipelines", pipelineSource)
+	}
+
+	gitState.BaseCommitSHA = commitSHA
+	if tag := os.Getenv("CI_COMMIT_TAG"); tag != "" {
+		gitState.BaseCommitRef = tagRefPrefix + tag
+		gitState.RefType = RefTypeTag
+		gitState.TagName = tag
+	} else if branch := os.Getenv("CI_COMMIT_BRANCH"); branch != "" {
+		gitState.BaseCommitRef = "refs/heads/" + branch
+		gitState.RefType = RefTypeBranch
+	}
+
+	return gitState, nil
+}
+
 func extractOwnerAndRepoFromGitURL(logger Logger, gitURL string) (string, string, error) {
 	re := regexp.MustCompile(`.*/(?P<owner>.*)/(?P<repo>.*).git`)
 	matches := re.FindStringSubmatch(gitURL)
@@ -395,15 +638,16 @@ func extractOwnerAndRepoFromGitURL(logger Logger, gitURL string) (string, string
 
 // DetermineUsedCISystem return CISystem bind to system in which image builder is running or error if unknown
 // It is used to avoid getting env variables in multiple parts of image builder
-func DetermineUsedCISystem() (CISystem, error) {
+// Built-in CI systems take precedence over the generic CI system configured with genericCI.
+func DetermineUsedCISystem(genericCI *GenericCIConfig) (CISystem, error) {
 	// Use system functions in production implementation
-	return determineUsedCISystem(os.Getenv, os.LookupEnv)
+	return determineUsedCISystem(os.Getenv, os.LookupEnv, genericCI)
 }
 
 // Additional private function for testing purposes.
 // It allows us to mock os.Getenv and os.LookupEnv during tests, keeping logic valid
 // Reason to introduce that is lack of possibility to override variables in CI systems
-func determineUsedCISystem(envGetter func(key string) string, envLookup func(key string) (string, bool)) (CISystem, error) {
+func determineUsedCISystem(envGetter func(key string) string, envLookup func(key string) (string, bool), genericCI *GenericCIConfig) (CISystem, error) {
 	// GITHUB_ACTIONS environment variable is always set to true in github actions workflow
 	// See: https://docs.github.com/en/actions/learn-github-actions/variables#default-environment-variables
 	isGithubActions := envGetter("GITHUB_ACTIONS")
@@ -424,5 +668,18 @@ func determineUsedCISystem(envGetter func(key string) string, envLookup func(key
 		return Jenkins, nil
 	}
 
+	// GITLAB_CI environment variable is always set to true in GitLab CI jobs
+	// See: https://docs.gitlab.com/ee/ci/variables/predefined_variables.html
+	if envGetter("GITLAB_CI") == "true" {
+		return GitLab, nil
+	}
+
+	// Environment variable set in the CI system configured in the generic-ci config section
+	if genericCI != nil && genericCI.DetectEnv != "" {
+		if _, isGeneric := envLookup(genericCI.DetectEnv); isGeneric {
+			return Generic, nil
+		}
+	}
+
 	return "", fmt.Errorf("cannot determine ci system: unknown system")
 }
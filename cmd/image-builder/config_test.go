@@ -78,6 +78,24 @@ default-pr-tag:
 	}
 }
 
+// genericGitHubLikeCI reads the git state of a GitHub-like CI system from env variables and the event file
+var genericGitHubLikeCI = GenericCIConfig{
+	DetectEnv:    "MY_CI",
+	EventFileEnv: "MY_CI_EVENT_FILE",
+	GitState: GitStateMapping{
+		RepositoryName:    ValueSource{Env: "MY_CI_REPO_NAME", EventPath: "repository.name"},
+		RepositoryOwner:   ValueSource{Env: "MY_CI_REPO_OWNER", EventPath: "repository.owner.login"},
+		PullRequestNumber: ValueSource{EventPath: "number"},
+		BaseCommitSHA:     ValueSource{Env: "MY_CI_SHA", EventPath: "pull_request.base.sha"},
+		BaseCommitRef:     ValueSource{EventPath: "pull_request.base.ref"},
+		PullHeadCommitSHA: ValueSource{EventPath: "pull_request.head.sha"},
+	},
+	PresubmitWhen: PresubmitRule{
+		ValueSource: ValueSource{Env: "MY_CI_EVENT"},
+		Equals:      []string{"pull_request", "pull_request_target"},
+	},
+}
+
 func TestLoadGitStateConfig(t *testing.T) {
 	tc := []struct {
 		name        string
@@ -102,9 +120,44 @@ func TestLoadGitStateConfig(t *testing.T) {
 				PullRequestNumber: 10410,
 				BaseCommitSHA:     "4b91c74a2aa9aeeb4a265cf1ffe2dd54812b4124",
 				PullHeadCommitSHA: "8d0172d980653a377317a8bff9a6bb6ec2334801",
+				BaseCommitRef:     "refs/heads/main",
+				RefType:           RefTypeBranch,
 				isPullRequest:     true,
 			},
 		},
+		{
+			name: "Load data from event payload for github pull_request",
+			options: options{
+				ciSystem: GithubActions,
+			},
+			env: map[string]string{
+				"GITHUB_EVENT_PATH": "./test_fixture/pull_request_opened.json",
+				"GITHUB_EVENT_NAME": "pull_request",
+			},
+			gitState: GitStateConfig{
+				RepositoryName:    "test-infra",
+				RepositoryOwner:   "kyma-project",
+				JobType:           "presubmit",
+				PullRequestNumber: 10410,
+				BaseCommitSHA:     "4b91c74a2aa9aeeb4a265cf1ffe2dd54812b4124",
+				PullHeadCommitSHA: "8d0172d980653a377317a8bff9a6bb6ec2334801",
+				BaseCommitRef:     "refs/heads/main",
+				RefType:           RefTypeBranch,
+				isPullRequest:     true,
+			},
+		},
+		{
+			name: "github pull_request event for pull request from fork, err",
+			options: options{
+				ciSystem: GithubActions,
+			},
+			env: map[string]string{
+				"GITHUB_EVENT_PATH": "./test_fixture/pull_request_target_reopened.json",
+				"GITHUB_EVENT_NAME": "pull_request",
+			},
+			expectError: true,
+			gitState:    GitStateConfig{},
+		},
 		{
 			name: "Load data from event payload for github push event",
 			options: options{
@@ -119,9 +172,65 @@ func TestLoadGitStateConfig(t *testing.T) {
 				RepositoryOwner: "KacperMalachowski",
 				JobType:         "postsubmit",
 				BaseCommitSHA:   "d42f5051757b3e0699eb979d7581404e36fc0eee",
+				BaseCommitRef:   "refs/heads/main",
+				RefType:         RefTypeBranch,
 				isPullRequest:   false,
 			},
 		},
+		{
+			name: "Load data from event payload for github tag push event",
+			options: options{
+				ciSystem: GithubActions,
+			},
+			env: map[string]string{
+				"GITHUB_EVENT_PATH": "./test_fixture/tag_push_event.json",
+				"GITHUB_EVENT_NAME": "push",
+			},
+			gitState: GitStateConfig{
+				RepositoryName:  "test-infra",
+				RepositoryOwner: "KacperMalachowski",
+				JobType:         "postsubmit",
+				BaseCommitSHA:   "d42f5051757b3e0699eb979d7581404e36fc0eee",
+				BaseCommitRef:   "refs/tags/v1.2.3",
+				RefType:         RefTypeTag,
+				TagName:         "v1.2.3",
+			},
+		},
+		{
+			name: "Load data from event payload for github release event",
+			options: options{
+				ciSystem: GithubActions,
+			},
+			env: map[string]string{
+				"GITHUB_EVENT_PATH": "./test_fixture/release_event.json",
+				"GITHUB_EVENT_NAME": "release",
+				"GITHUB_SHA":        "d42f5051757b3e0699eb979d7581404e36fc0eee",
+				"GITHUB_REF":        "refs/tags/v1.2.3",
+			},
+			gitState: GitStateConfig{
+				RepositoryName:  "test-infra",
+				RepositoryOwner: "KacperMalachowski",
+				JobType:         "postsubmit",
+				BaseCommitSHA:   "d42f5051757b3e0699eb979d7581404e36fc0eee",
+				BaseCommitRef:   "refs/tags/v1.2.3",
+				RefType:         RefTypeTag,
+				TagName:         "v1.2.3",
+			},
+		},
+		{
+			name: "github release event for edited release, err",
+			options: options{
+				ciSystem: GithubActions,
+			},
+			env: map[string]string{
+				"GITHUB_EVENT_PATH": "./test_fixture/release_edited_event.json",
+				"GITHUB_EVENT_NAME": "release",
+				"GITHUB_SHA":        "d42f5051757b3e0699eb979d7581404e36fc0eee",
+				"GITHUB_REF":        "refs/tags/v1.2.3",
+			},
+			expectError: true,
+			gitState:    GitStateConfig{},
+		},
 		{
 			name: "Load data from event payload for github workflow_dispatch event",
 			options: options{
@@ -181,7 +290,7 @@ func TestLoadGitStateConfig(t *testing.T) {
 			},
 			env: map[string]string{
 				"GITHUB_EVENT_PATH": "./test_fixture/pull_request_target_reopened.json",
-				"GITHUB_EVENT_NAME": "pull_request",
+				"GITHUB_EVENT_NAME": "issue_comment",
 			},
 			expectError: true,
 			gitState:    GitStateConfig{},
@@ -249,6 +358,162 @@ func TestLoadGitStateConfig(t *testing.T) {
 				isPullRequest:     true,
 			},
 		},
+		{
+			name: "load data from merge request pipeline for gitlab",
+			options: options{
+				ciSystem: GitLab,
+			},
+			env: map[string]string{
+				"CI_PROJECT_NAME":                     "test-infra",
+				"CI_PROJECT_NAMESPACE":                "kyma-project",
+				"CI_PIPELINE_SOURCE":                  "merge_request_event",
+				"CI_COMMIT_SHA":                       "1234",
+				"CI_MERGE_REQUEST_IID":                "14",
+				"CI_MERGE_REQUEST_DIFF_BASE_SHA":      "4321",
+				"CI_MERGE_REQUEST_TARGET_BRANCH_NAME": "main",
+			},
+			gitState: GitStateConfig{
+				RepositoryName:    "test-infra",
+				RepositoryOwner:   "kyma-project",
+				JobType:           "presubmit",
+				BaseCommitSHA:     "4321",
+				BaseCommitRef:     "refs/heads/main",
+				PullRequestNumber: 14,
+				PullHeadCommitSHA: "1234",
+				RefType:           RefTypeBranch,
+				isPullRequest:     true,
+			},
+		},
+		{
+			name: "load data from merged results pipeline for gitlab",
+			options: options{
+				ciSystem: GitLab,
+			},
+			env: map[string]string{
+				"CI_PROJECT_NAME":                    "test-infra",
+				"CI_PROJECT_NAMESPACE":               "kyma-project/tools",
+				"CI_PIPELINE_SOURCE":                 "merge_request_event",
+				"CI_COMMIT_SHA":                      "merge-commit",
+				"CI_MERGE_REQUEST_IID":               "14",
+				"CI_MERGE_REQUEST_DIFF_BASE_SHA":     "4321",
+				"CI_MERGE_REQUEST_SOURCE_BRANCH_SHA": "1234",
+			},
+			gitState: GitStateConfig{
+				RepositoryName:    "test-infra",
+				RepositoryOwner:   "kyma-project/tools",
+				JobType:           "presubmit",
+				BaseCommitSHA:     "4321",
+				PullRequestNumber: 14,
+				PullHeadCommitSHA: "1234",
+				RefType:           RefTypeBranch,
+				isPullRequest:     true,
+			},
+		},
+		{
+			name: "load data from branch push pipeline for gitlab",
+			options: options{
+				ciSystem: GitLab,
+			},
+			env: map[string]string{
+				"CI_PROJECT_NAME":      "test-infra",
+				"CI_PROJECT_NAMESPACE": "kyma-project",
+				"CI_PIPELINE_SOURCE":   "push",
+				"CI_COMMIT_SHA":        "1234",
+				"CI_COMMIT_BRANCH":     "main",
+			},
+			gitState: GitStateConfig{
+				RepositoryName:  "test-infra",
+				RepositoryOwner: "kyma-project",
+				JobType:         "postsubmit",
+				BaseCommitSHA:   "1234",
+				BaseCommitRef:   "refs/heads/main",
+				RefType:         RefTypeBranch,
+			},
+		},
+		{
+			name: "load data from tag push pipeline for gitlab",
+			options: options{
+				ciSystem: GitLab,
+			},
+			env: map[string]string{
+				"CI_PROJECT_NAME":      "test-infra",
+				"CI_PROJECT_NAMESPACE": "kyma-project",
+				"CI_PIPELINE_SOURCE":   "push",
+				"CI_COMMIT_SHA":        "1234",
+				"CI_COMMIT_TAG":        "v1.0.0",
+			},
+			gitState: GitStateConfig{
+				RepositoryName:  "test-infra",
+				RepositoryOwner: "kyma-project",
+				JobType:         "postsubmit",
+				BaseCommitSHA:   "1234",
+				BaseCommitRef:   "refs/tags/v1.0.0",
+				RefType:         RefTypeTag,
+				TagName:         "v1.0.0",
+			},
+		},
+		{
+			name: "load data from event file for generic ci system",
+			options: options{
+				ciSystem: Generic,
+				Config:   Config{GenericCI: &genericGitHubLikeCI},
+			},
+			env: map[string]string{
+				"MY_CI_EVENT_FILE": "./test_fixture/pull_request_target_reopened.json",
+				"MY_CI_EVENT":      "pull_request",
+			},
+			gitState: GitStateConfig{
+				RepositoryName:    "test-infra",
+				RepositoryOwner:   "kyma-project",
+				JobType:           "presubmit",
+				BaseCommitSHA:     "4b91c74a2aa9aeeb4a265cf1ffe2dd54812b4124",
+				BaseCommitRef:     "main",
+				PullRequestNumber: 10410,
+				PullHeadCommitSHA: "8d0172d980653a377317a8bff9a6bb6ec2334801",
+				isPullRequest:     true,
+			},
+		},
+		{
+			name: "load postsubmit data from env for generic ci system",
+			options: options{
+				ciSystem: Generic,
+				Config:   Config{GenericCI: &genericGitHubLikeCI},
+			},
+			env: map[string]string{
+				"MY_CI_EVENT":      "push",
+				"MY_CI_REPO_NAME":  "test-infra",
+				"MY_CI_REPO_OWNER": "kyma-project",
+				"MY_CI_SHA":        "1234",
+			},
+			gitState: GitStateConfig{
+				RepositoryName:  "test-infra",
+				RepositoryOwner: "kyma-project",
+				JobType:         "postsubmit",
+				BaseCommitSHA:   "1234",
+			},
+		},
+		{
+			name: "generic ci system without config",
+			options: options{
+				ciSystem: Generic,
+			},
+			gitState:    GitStateConfig{},
+			expectError: true,
+		},
+		{
+			name: "unsupported pipeline source for gitlab",
+			options: options{
+				ciSystem: GitLab,
+			},
+			env: map[string]string{
+				"CI_PROJECT_NAME":      "test-infra",
+				"CI_PROJECT_NAMESPACE": "kyma-project",
+				"CI_PIPELINE_SOURCE":   "chat",
+				"CI_COMMIT_SHA":        "1234",
+			},
+			gitState:    GitStateConfig{},
+			expectError: true,
+		},
 	}
 
 	for _, c := range tc {
@@ -266,7 +531,7 @@ func TestLoadGitStateConfig(t *testing.T) {
 			logger := zapLogger.Sugar()
 
 			// Load git state
-			state, err := LoadGitStateConfig(logger, c.options.ciSystem)
+			state, err := LoadGitStateConfig(logger, c.options.ciSystem, c.options.GenericCI)
 			if err != nil && !c.expectError {
 				t.Errorf("unexpected error occured %s", err)
 			}
@@ -296,6 +561,7 @@ func Test_determineCISystem(t *testing.T) {
 	tc := []struct {
 		name      string
 		env       mockEnv
+		genericCI *GenericCIConfig
 		ciSystem  CISystem
 		expectErr bool
 	}{
@@ -313,6 +579,39 @@ func Test_determineCISystem(t *testing.T) {
 			},
 			ciSystem: Jenkins,
 		},
+		{
+			name: "detect running in gitlab ci",
+			env: mockEnv{
+				"GITLAB_CI": "true",
+			},
+			ciSystem: GitLab,
+		},
+		{
+			name: "detect running in generic ci system",
+			env: mockEnv{
+				"MY_CI": "1",
+			},
+			genericCI: &GenericCIConfig{DetectEnv: "MY_CI"},
+			ciSystem:  Generic,
+		},
+		{
+			name: "built-in ci system takes precedence over generic ci system",
+			env: mockEnv{
+				"GITHUB_ACTIONS": "true",
+				"MY_CI":          "1",
+			},
+			genericCI: &GenericCIConfig{DetectEnv: "MY_CI"},
+			ciSystem:  GithubActions,
+		},
+		{
+			name: "generic ci system configured, but not detected",
+			env: mockEnv{
+				"GITHUB_ACTIONS": "false",
+			},
+			genericCI: &GenericCIConfig{DetectEnv: "MY_CI"},
+			ciSystem:  "",
+			expectErr: true,
+		},
 		{
 			name: "unknown ci system",
 			env: mockEnv{
@@ -327,7 +626,7 @@ func Test_determineCISystem(t *testing.T) {
 
 	for _, c := range tc {
 		t.Run(c.name, func(t *testing.T) {
-			ciSystem, err := determineUsedCISystem(c.env.mockGetenv, c.env.mockLookupEnv)
+			ciSystem, err := determineUsedCISystem(c.env.mockGetenv, c.env.mockLookupEnv, c.genericCI)
 			if err != nil && !c.expectErr {
 				t.Errorf("got unexpected error: %s", err)
 			}
@@ -341,3 +640,54 @@ func Test_determineCISystem(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_targetRegistries(t *testing.T) {
+	config := Config{
+		Registry:    Registry{"europe-docker.pkg.dev/kyma-project/prod"},
+		DevRegistry: Registry{"europe-docker.pkg.dev/kyma-project/dev"},
+	}
+	tc := []struct {
+		name     string
+		config   Config
+		gitState GitStateConfig
+		expected Registry
+	}{
+		{
+			name:     "pull request is pushed to dev registry",
+			config:   config,
+			gitState: GitStateConfig{JobType: "presubmit", isPullRequest: true},
+			expected: config.DevRegistry,
+		},
+		{
+			name:     "merge group is pushed to dev registry",
+			config:   config,
+			gitState: GitStateConfig{JobType: "merge_group"},
+			expected: config.DevRegistry,
+		},
+		{
+			name:     "postsubmit is pushed to registry",
+			config:   config,
+			gitState: GitStateConfig{JobType: "postsubmit"},
+			expected: config.Registry,
+		},
+		{
+			name:     "release is pushed to registry",
+			config:   config,
+			gitState: GitStateConfig{JobType: "postsubmit", RefType: RefTypeTag, TagName: "v1.0.0"},
+			expected: config.Registry,
+		},
+		{
+			name:     "pull request is pushed to registry without dev registry",
+			config:   Config{Registry: config.Registry},
+			gitState: GitStateConfig{JobType: "presubmit", isPullRequest: true},
+			expected: config.Registry,
+		},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.config.targetRegistries(c.gitState); !reflect.DeepEqual(got, c.expected) {
+				t.Errorf("targetRegistries() = %v, expected %v", got, c.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Generic is the CI system configured in the image-builder config.
+// Its git state is read from environment variables and the event file mapped in the generic-ci config section.
+const Generic CISystem = "Generic"
+
+// GenericCIConfig maps values provided by a CI system to the git state.
+// It allows using image-builder in CI systems without a built-in git state loader.
+type GenericCIConfig struct {
+	// DetectEnv is the name of the environment variable which is set only in the CI system.
+	// The generic CI system is used if none of the built-in CI systems is detected and this variable is set.
+	DetectEnv string `yaml:"detect-env" json:"detect-env"`
+	// EventFileEnv is the name of the environment variable holding the path to the JSON event file.
+	// It's required only if any value is read from the event file.
+	EventFileEnv string `yaml:"event-file-env,omitempty" json:"event-file-env,omitempty"`
+	// GitState maps values provided by the CI system to git state fields
+	GitState GitStateMapping `yaml:"git-state" json:"git-state"`
+	// PresubmitWhen decides if the job is a presubmit job. Jobs not matching the rule are postsubmit jobs.
+	PresubmitWhen PresubmitRule `yaml:"presubmit-when" json:"presubmit-when"`
+}
+
+// GitStateMapping defines the source of each GitStateConfig field.
+type GitStateMapping struct {
+	RepositoryName    ValueSource `yaml:"repository-name" json:"repository-name"`
+	RepositoryOwner   ValueSource `yaml:"repository-owner" json:"repository-owner"`
+	PullRequestNumber ValueSource `yaml:"pull-request-number,omitempty" json:"pull-request-number,omitempty"`
+	BaseCommitSHA     ValueSource `yaml:"base-commit-sha" json:"base-commit-sha"`
+	BaseCommitRef     ValueSource `yaml:"base-commit-ref,omitempty" json:"base-commit-ref,omitempty"`
+	PullHeadCommitSHA ValueSource `yaml:"pull-head-commit-sha,omitempty" json:"pull-head-commit-sha,omitempty"`
+}
+
+// ValueSource defines where the value is read from.
+// If both fields are set, the environment variable takes precedence and the event file is used when the variable is empty.
+type ValueSource struct {
+	// Env is the name of the environment variable holding the value
+	Env string `yaml:"env,omitempty" json:"env,omitempty"`
+	// EventPath is the dot-separated path to the value in the JSON event file, e.g. pull_request.head.sha
+	// Elements of arrays are selected by index, e.g. commits.0.id
+	EventPath string `yaml:"event-path,omitempty" json:"event-path,omitempty"`
+}
+
+// IsSet returns true if any source of the value is configured.
+func (s ValueSource) IsSet() bool {
+	return s.Env != "" || s.EventPath != ""
+}
+
+// PresubmitRule decides if the job is a presubmit job based on a value provided by the CI system.
+type PresubmitRule struct {
+	// ValueSource is the source of the value the rule is evaluated for
+	ValueSource `yaml:",inline"`
+	// Equals is a list of values marking presubmit jobs.
+	// If it's empty, the job is a presubmit job when the value is not empty.
+	Equals []string `yaml:"equals,omitempty" json:"equals,omitempty"`
+}
+
+// matches returns true if the value marks the job as a presubmit job.
+func (r PresubmitRule) matches(value string) bool {
+	if len(r.Equals) == 0 {
+		return value != ""
+	}
+	return slices.Contains(r.Equals, value)
+}
+
+// Validate checks all values required to load the git state have a source.
+func (c GenericCIConfig) Validate() []error {
+	var errs []error
+	if c.DetectEnv == "" {
+		errs = append(errs, fmt.Errorf("generic-ci: detect-env is required"))
+	}
+	required := map[string]ValueSource{
+		"repository-name":      c.GitState.RepositoryName,
+		"repository-owner":     c.GitState.RepositoryOwner,
+		"base-commit-sha":      c.GitState.BaseCommitSHA,
+		"pull-request-number":  c.GitState.PullRequestNumber,
+		"pull-head-commit-sha": c.GitState.PullHeadCommitSHA,
+		"presubmit-when":       c.PresubmitWhen.ValueSource,
+	}
+	fields := make([]string, 0, len(required))
+	for field := range required {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+	usesEventFile := false
+	for _, field := range fields {
+		source := required[field]
+		if !source.IsSet() {
+			errs = append(errs, fmt.Errorf("generic-ci: %s must define env or event-path", field))
+		}
+		usesEventFile = usesEventFile || source.EventPath != ""
+	}
+	if usesEventFile && c.EventFileEnv == "" {
+		errs = append(errs, fmt.Errorf("generic-ci: event-file-env is required when values are read from the event file"))
+	}
+	return errs
+}
+
+// genericValueReader reads values from environment variables and the JSON event file.
+type genericValueReader struct {
+	envLookup func(key string) (string, bool)
+	event     any
+}
+
+// read returns the value from the source. It returns an empty string if the value is not set.
+func (r genericValueReader) read(source ValueSource) (string, error) {
+	if source.Env != "" {
+		if value, present := r.envLookup(source.Env); present && value != "" {
+			return value, nil
+		}
+	}
+	// Not all builds provide the event file, e.g. scheduled ones, so the value is empty in that case
+	if source.EventPath == "" || r.event == nil {
+		return "", nil
+	}
+	return lookupEventPath(r.event, source.EventPath)
+}
+
+// lookupEventPath returns the value from the decoded JSON document at the dot-separated path.
+// It returns an empty string if the path doesn't exist or points to a null value.
+func lookupEventPath(document any, path string) (string, error) {
+	current := document
+	for _, key := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]any:
+			current = node[key]
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return "", nil
+			}
+			current = node[index]
+		default:
+			return "", nil
+		}
+	}
+
+	switch value := current.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	default:
+		return "", fmt.Errorf("event path %s doesn't point to a scalar value", path)
+	}
+}
+
+// loadGenericGitState reads the git state using the mapping from the generic-ci config section.
+func loadGenericGitState(logger Logger, c GenericCIConfig) (GitStateConfig, error) {
+	return loadGenericGitStateFromEnv(logger, c, os.LookupEnv)
+}
+
+func loadGenericGitStateFromEnv(logger Logger, c GenericCIConfig, envLookup func(key string) (string, bool)) (GitStateConfig, error) {
+	reader := genericValueReader{envLookup: envLookup}
+	if c.EventFileEnv != "" {
+		if eventPath, present := envLookup(c.EventFileEnv); present && eventPath != "" {
+			data, err := os.ReadFile(eventPath)
+			if err != nil {
+				return GitStateConfig{}, fmt.Errorf("failed to read content of event file: %w", err)
+			}
+			decoder := json.NewDecoder(bytes.NewReader(data))
+			// Keep numbers as written, so PR numbers and IDs are not converted to floats
+			decoder.UseNumber()
+			if err := decoder.Decode(&reader.event); err != nil {
+				return GitStateConfig{}, fmt.Errorf("failed to parse event file: %w", err)
+			}
+		}
+	}
+
+	values := make(map[string]string)
+	fields := []struct {
+		name   string
+		source ValueSource
+	}{
+		{"repository-name", c.GitState.RepositoryName},
+		{"repository-owner", c.GitState.RepositoryOwner},
+		{"pull-request-number", c.GitState.PullRequestNumber},
+		{"base-commit-sha", c.GitState.BaseCommitSHA},
+		{"base-commit-ref", c.GitState.BaseCommitRef},
+		{"pull-head-commit-sha", c.GitState.PullHeadCommitSHA},
+		{"presubmit-when", c.PresubmitWhen.ValueSource},
+	}
+	for _, field := range fields {
+		value, err := reader.read(field.source)
+		if err != nil {
+			return GitStateConfig{}, fmt.Errorf("failed to read %s: %w", field.name, err)
+		}
+		values[field.name] = value
+	}
+	logger.Debugw("Read generic CI values", "values", values)
+
+	for _, field := range []string{"repository-name", "repository-owner"} {
+		if values[field] == "" {
+			return GitStateConfig{}, fmt.Errorf("generic CI value %s is empty, please check generic-ci config", field)
+		}
+	}
+
+	gitState := GitStateConfig{
+		RepositoryName:  values["repository-name"],
+		RepositoryOwner: values["repository-owner"],
+		BaseCommitSHA:   values["base-commit-sha"],
+		BaseCommitRef:   values["base-commit-ref"],
+	}
+
+	if c.PresubmitWhen.matches(values["presubmit-when"]) {
+		pullNumber, err := strconv.Atoi(values["pull-request-number"])
+		if err != nil {
+			return GitStateConfig{}, fmt.Errorf("generic CI value pull-request-number contains invalid value, please set it to correct integer PR number: %w", err)
+		}
+		if values["pull-head-commit-sha"] == "" {
+			return GitStateConfig{}, fmt.Errorf("generic CI value pull-head-commit-sha is empty, please check generic-ci config")
+		}
+		gitState.JobType = "presubmit"
+		gitState.PullRequestNumber = pullNumber
+		gitState.PullHeadCommitSHA = values["pull-head-commit-sha"]
+		gitState.isPullRequest = true
+		return gitState, nil
+	}
+
+	if gitState.BaseCommitSHA == "" {
+		return GitStateConfig{}, fmt.Errorf("generic CI value base-commit-sha is empty, please check generic-ci config")
+	}
+	gitState.JobType = "postsubmit"
+	return gitState, nil
+}
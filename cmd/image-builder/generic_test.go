@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+)
+
+func Test_lookupEventPath(t *testing.T) {
+	document := map[string]any{
+		"repository": map[string]any{"name": "test-infra"},
+		"commits":    []any{map[string]any{"id": "1234"}},
+		"draft":      false,
+		"head":       nil,
+	}
+
+	tc := []struct {
+		name      string
+		path      string
+		expected  string
+		expectErr bool
+	}{
+		{name: "nested object value", path: "repository.name", expected: "test-infra"},
+		{name: "array element value", path: "commits.0.id", expected: "1234"},
+		{name: "boolean value", path: "draft", expected: "false"},
+		{name: "null value", path: "head", expected: ""},
+		{name: "missing key", path: "repository.owner.login", expected: ""},
+		{name: "array index out of range", path: "commits.1.id", expected: ""},
+		{name: "path to object, fail", path: "repository", expectErr: true},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := lookupEventPath(document, c.path)
+			if err != nil && !c.expectErr {
+				t.Errorf("got unexpected error: %s", err)
+			}
+			if err == nil && c.expectErr {
+				t.Error("error expected, but no one occured")
+			}
+			if got != c.expected {
+				t.Errorf("lookupEventPath(): Got %q, but expected %q", got, c.expected)
+			}
+		})
+	}
+}
+
+func TestGenericCIConfig_Validate(t *testing.T) {
+	if errs := genericGitHubLikeCI.Validate(); len(errs) > 0 {
+		t.Errorf("got unexpected errors: %v", errs)
+	}
+
+	// detect-env, repository-owner, base-commit-sha, pull-request-number, pull-head-commit-sha, presubmit-when, event-file-env
+	errs := GenericCIConfig{GitState: GitStateMapping{RepositoryName: ValueSource{EventPath: "repository.name"}}}.Validate()
+	if len(errs) != 7 {
+		t.Errorf("expected 7 errors, got %d: %v", len(errs), errs)
+	}
+}
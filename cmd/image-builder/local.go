@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kyma-project/test-infra/pkg/imagebuilder"
+	"github.com/kyma-project/test-infra/pkg/tags"
+)
+
+// defaultPlatforms are the platforms used for building the image, when none are provided with the --platform flag.
+var defaultPlatforms = []string{"linux/amd64", "linux/arm64"}
+
+// redactedArg replaces values of docker buildx arguments which can contain tag values in logs.
+const redactedArg = "[REDACTED]"
+
+// commandRunner runs the external command and writes its standard output to stdout.
+type commandRunner func(ctx context.Context, stdout io.Writer, name string, args ...string) error
+
+// runCommand is the default commandRunner executing the command on the local machine.
+func runCommand(ctx context.Context, stdout io.Writer, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stdout
+	return cmd.Run()
+}
+
+// localBackend builds images on the local machine using docker buildx and BuildKit.
+// It uses the same tags, build args, platforms, target and cache settings as the ADO backend,
+// so builds can be reproduced on developer machines and in air-gapped runners.
+type localBackend struct {
+	run commandRunner
+}
+
+func newLocalBackend() *localBackend {
+	return &localBackend{run: runCommand}
+}
+
+// Build builds the image with docker buildx and returns the build result.
+// If the docker buildx command fails, the result has the failed status and the error is not returned.
+func (b *localBackend) Build(ctx context.Context, o options) (*BuildResult, error) {
+	fmt.Println("Building image locally with docker buildx.")
+	logger := o.logger.With("backend", LocalBackend)
+
+	if o.async {
+		return nil, fmt.Errorf("build locally failed, async mode is supported only by the %s backend", ADOBackend)
+	}
+
+	if len(o.Registry) == 0 {
+		return nil, fmt.Errorf("build locally failed, no registry configured")
+	}
+
+	if !o.isCI && o.gitState.BaseCommitSHA == "" {
+		gitState, err := b.loadLocalGitState(ctx, o.context)
+		if err != nil {
+			return nil, fmt.Errorf("build locally failed, failed loading git state from build context: %w", err)
+		}
+		o.gitState = gitState
+		logger.Debugw("Git state loaded from build context", "gitState", o.gitState)
+	}
+
+	parsedTags, err := parseTags(logger, o)
+	if err != nil {
+		return nil, fmt.Errorf("build locally failed, failed parsing tags: %w", err)
+	}
+
+	metadataFile, err := os.CreateTemp("", "image-builder-metadata-*.json")
+	if err != nil {
+		return nil, fmt.Errorf("build locally failed, failed creating buildx metadata file: %w", err)
+	}
+	metadataFile.Close()
+	defer os.Remove(metadataFile.Name())
+
+	args := buildxArgs(o, parsedTags, metadataFile.Name())
+	logger.Debugw("Running docker buildx", "args", loggedBuildxArgs(args), "tags", tagNames(parsedTags))
+
+	stdout := o.buildOutput()
+	if o.silent {
+		stdout = io.Discard
+	}
+
+	registries := o.targetRegistries(o.gitState)
+	report := &imagebuilder.BuildReport{
+		Status:        "Succeeded",
+		IsPushed:      o.localPush,
+		Name:          o.name,
+		Images:        imageReferences(registries, o.name, parsedTags),
+		Tags:          tagValues(parsedTags),
+		RegistryURL:   registries[0],
+		Architectures: buildPlatforms(o),
+		Registries:    registries,
+	}
+
+	err = b.run(ctx, stdout, "docker", args...)
+	if err != nil {
+		fmt.Printf("docker buildx build failed, err: %s\n", err)
+		report.Status = "Failed"
+		report.IsPushed = false
+		return &BuildResult{Status: BuildStatusFailed, Report: report}, nil
+	}
+
+	report.Digest, err = readBuildxDigest(metadataFile.Name())
+	if err != nil {
+		return nil, fmt.Errorf("build locally failed, failed reading image digest: %w", err)
+	}
+	logger.Debugw("Image built locally", "buildReport", report)
+
+	return &BuildResult{Status: BuildStatusSucceeded, Report: report}, nil
+}
+
+// loadLocalGitState reads the HEAD commit of the repository holding the build context.
+// It's used to compute default tags when image-builder is not running in CI.
+func (b *localBackend) loadLocalGitState(ctx context.Context, buildContext string) (GitStateConfig, error) {
+	var out bytes.Buffer
+	err := b.run(ctx, &out, "git", "-C", buildContext, "rev-parse", "HEAD")
+	if err != nil {
+		return GitStateConfig{}, fmt.Errorf("failed reading HEAD commit: %w", err)
+	}
+	return GitStateConfig{
+		JobType:       "postsubmit",
+		BaseCommitSHA: strings.TrimSpace(out.String()),
+	}, nil
+}
+
+// buildxArgs returns arguments for the docker buildx build command.
+func buildxArgs(o options, parsedTags []tags.Tag, metadataFilePath string) []string {
+	args := []string{"buildx", "build",
+		"--file", filepath.Join(o.context, o.dockerfile),
+		"--platform", strings.Join(buildPlatforms(o), ","),
+		"--metadata-file", metadataFilePath,
+	}
+
+	for _, image := range imageReferences(o.targetRegistries(o.gitState), o.name, parsedTags) {
+		args = append(args, "--tag", image)
+	}
+
+	for _, arg := range o.buildArgs {
+		args = append(args, "--build-arg", fmt.Sprintf("%s=%s", arg.Name, arg.Value))
+	}
+
+	if o.exportTags {
+		for _, tag := range parsedTags {
+			args = append(args, "--build-arg", fmt.Sprintf("TAG_%s=%s", tag.Name, tag.Value))
+		}
+	}
+
+	if o.target != "" {
+		args = append(args, "--target", o.target)
+	}
+
+	if o.Cache.Enabled && o.Cache.CacheRepo != "" {
+		// Run and copy layers are cached only with max mode, which exports all intermediate layers.
+		mode := "min"
+		if o.Cache.CacheRunLayers || o.Cache.CacheCopyLayers {
+			mode = "max"
+		}
+		args = append(args,
+			"--cache-from", fmt.Sprintf("type=registry,ref=%s", o.Cache.CacheRepo),
+			"--cache-to", fmt.Sprintf("type=registry,ref=%s,mode=%s", o.Cache.CacheRepo, mode),
+		)
+	}
+
+	if o.localPush {
+		args = append(args, "--push")
+	} else if len(buildPlatforms(o)) == 1 {
+		// Docker image store can hold only single platform images.
+		args = append(args, "--load")
+	}
+
+	return append(args, o.context)
+}
+
+// buildPlatforms returns platforms the image is built for.
+func buildPlatforms(o options) []string {
+	if len(o.platforms) > 0 {
+		return o.platforms
+	}
+	return defaultPlatforms
+}
+
+// imageReferences returns full image references for all combinations of registries and tags.
+func imageReferences(registries []string, name string, parsedTags []tags.Tag) []string {
+	var images []string
+	for _, registry := range registries {
+		for _, tag := range parsedTags {
+			images = append(images, fmt.Sprintf("%s/%s:%s", registry, name, tag.Value))
+		}
+	}
+	return images
+}
+
+// tagValues returns values of the parsed tags.
+func tagValues(parsedTags []tags.Tag) []string {
+	var values []string
+	for _, tag := range parsedTags {
+		values = append(values, tag.Value)
+	}
+	return values
+}
+
+// tagNames returns names of the parsed tags.
+// Tag values can be rendered from sensitive environment variables, so only names are logged.
+func tagNames(parsedTags []tags.Tag) []string {
+	var names []string
+	for _, tag := range parsedTags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+// loggedBuildxArgs returns docker buildx arguments with image references and tag build args redacted, see tagNames.
+func loggedBuildxArgs(args []string) []string {
+	logged := slices.Clone(args)
+	for i := 1; i < len(logged); i++ {
+		switch {
+		case logged[i-1] == "--tag":
+			logged[i] = redactedArg
+		case logged[i-1] == "--build-arg" && strings.HasPrefix(logged[i], "TAG_"):
+			argName, _, _ := strings.Cut(logged[i], "=")
+			logged[i] = argName + "=" + redactedArg
+		}
+	}
+	return logged
+}
+
+// readBuildxDigest reads the image digest from the docker buildx metadata file.
+func readBuildxDigest(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed reading buildx metadata file: %w", err)
+	}
+	var metadata struct {
+		Digest string `json:"containerimage.digest"`
+	}
+	if len(data) == 0 {
+		// Buildx doesn't write metadata when the image is not exported.
+		return "", nil
+	}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return "", fmt.Errorf("failed parsing buildx metadata file: %w", err)
+	}
+	return metadata.Digest, nil
+}
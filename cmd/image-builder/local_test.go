@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kyma-project/test-infra/pkg/imagebuilder"
+	"github.com/kyma-project/test-infra/pkg/sets"
+	"github.com/kyma-project/test-infra/pkg/tags"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_buildxArgs(t *testing.T) {
+	parsedTags := []tags.Tag{{Name: "default_tag", Value: "v20240101-abcdef12"}}
+	tc := []struct {
+		name     string
+		options  options
+		expected []string
+	}{
+		{
+			name: "defaults, multi-platform build without push",
+			options: options{
+				Config:     Config{Registry: Registry{"europe-docker.pkg.dev/kyma-project/prod"}},
+				context:    ".",
+				dockerfile: "Dockerfile",
+				name:       "test-image",
+			},
+			expected: []string{"buildx", "build",
+				"--file", "Dockerfile",
+				"--platform", "linux/amd64,linux/arm64",
+				"--metadata-file", "/tmp/metadata.json",
+				"--tag", "europe-docker.pkg.dev/kyma-project/prod/test-image:v20240101-abcdef12",
+				".",
+			},
+		},
+		{
+			name: "all settings, single platform build with push",
+			options: options{
+				Config: Config{
+					Registry: Registry{"reg1", "reg2"},
+					Cache: CacheConfig{
+						Enabled:        true,
+						CacheRunLayers: true,
+						CacheRepo:      "reg1/cache",
+					},
+				},
+				context:    "build",
+				dockerfile: "images/Dockerfile",
+				name:       "test-image",
+				buildArgs:  sets.Tags{{Name: "BIN", Value: "test"}},
+				platforms:  sets.Strings{"linux/amd64"},
+				exportTags: true,
+				target:     "release",
+				localPush:  true,
+			},
+			expected: []string{"buildx", "build",
+				"--file", "build/images/Dockerfile",
+				"--platform", "linux/amd64",
+				"--metadata-file", "/tmp/metadata.json",
+				"--tag", "reg1/test-image:v20240101-abcdef12",
+				"--tag", "reg2/test-image:v20240101-abcdef12",
+				"--build-arg", "BIN=test",
+				"--build-arg", "TAG_default_tag=v20240101-abcdef12",
+				"--target", "release",
+				"--cache-from", "type=registry,ref=reg1/cache",
+				"--cache-to", "type=registry,ref=reg1/cache,mode=max",
+				"--push",
+				"build",
+			},
+		},
+		{
+			name: "single platform build without push is loaded to docker",
+			options: options{
+				Config:     Config{Registry: Registry{"reg"}},
+				context:    ".",
+				dockerfile: "Dockerfile",
+				name:       "test-image",
+				platforms:  sets.Strings{"linux/arm64"},
+			},
+			expected: []string{"buildx", "build",
+				"--file", "Dockerfile",
+				"--platform", "linux/arm64",
+				"--metadata-file", "/tmp/metadata.json",
+				"--tag", "reg/test-image:v20240101-abcdef12",
+				"--load",
+				".",
+			},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			got := buildxArgs(c.options, parsedTags, "/tmp/metadata.json")
+			if !reflect.DeepEqual(got, c.expected) {
+				t.Errorf("buildxArgs(): got %v, want %v", got, c.expected)
+			}
+		})
+	}
+}
+
+func Test_localBackend_Build(t *testing.T) {
+	tc := []struct {
+		name           string
+		buildErr       error
+		expectedResult *BuildResult
+		expectErr      bool
+	}{
+		{
+			name: "build succeeded",
+			expectedResult: &BuildResult{
+				Status: BuildStatusSucceeded,
+				Report: &imagebuilder.BuildReport{
+					Status:        "Succeeded",
+					IsPushed:      true,
+					Name:          "test-image",
+					Images:        []string{"dev-reg/test-image:PR-5"},
+					Digest:        "sha256:abc",
+					Tags:          []string{"PR-5"},
+					RegistryURL:   "dev-reg",
+					Architectures: []string{"linux/amd64"},
+					Registries:    []string{"dev-reg"},
+				},
+			},
+		},
+		{
+			name:     "build failed",
+			buildErr: fmt.Errorf("exit status 1"),
+			expectedResult: &BuildResult{
+				Status: BuildStatusFailed,
+				Report: &imagebuilder.BuildReport{
+					Status:        "Failed",
+					Name:          "test-image",
+					Images:        []string{"dev-reg/test-image:PR-5"},
+					Tags:          []string{"PR-5"},
+					RegistryURL:   "dev-reg",
+					Architectures: []string{"linux/amd64"},
+					Registries:    []string{"dev-reg"},
+				},
+			},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			backend := &localBackend{
+				run: func(_ context.Context, _ io.Writer, name string, args ...string) error {
+					if c.buildErr != nil {
+						return c.buildErr
+					}
+					// Metadata file path follows the --metadata-file flag.
+					for i, arg := range args {
+						if arg == "--metadata-file" {
+							return os.WriteFile(args[i+1], []byte(`{"containerimage.digest":"sha256:abc"}`), 0644)
+						}
+					}
+					return fmt.Errorf("missing --metadata-file flag")
+				},
+			}
+			o := options{
+				Config: Config{
+					Registry:     Registry{"reg"},
+					DevRegistry:  Registry{"dev-reg"},
+					DefaultPRTag: defaultPRTag,
+				},
+				logger:     zap.NewNop().Sugar(),
+				context:    ".",
+				dockerfile: "Dockerfile",
+				name:       "test-image",
+				platforms:  sets.Strings{"linux/amd64"},
+				localPush:  true,
+				silent:     true,
+				isCI:       true,
+				gitState:   prGitState,
+			}
+
+			got, err := backend.Build(context.Background(), o)
+			if err != nil && !c.expectErr {
+				t.Errorf("got unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(got, c.expectedResult) {
+				t.Errorf("Build(): got %+v, want %+v", got.Report, c.expectedResult.Report)
+			}
+		})
+	}
+}
+
+func Test_newBuildBackend(t *testing.T) {
+	tc := []struct {
+		name      string
+		options   options
+		expected  BuildBackend
+		expectErr bool
+	}{
+		{
+			name:     "default ado backend",
+			expected: &adoBackend{},
+		},
+		{
+			name:     "backend from config",
+			options:  options{Config: Config{BuildBackend: LocalBackend}},
+			expected: &localBackend{},
+		},
+		{
+			name:     "flag overrides config",
+			options:  options{Config: Config{BuildBackend: LocalBackend}, backend: ADOBackend},
+			expected: &adoBackend{},
+		},
+		{
+			name:      "unknown backend",
+			options:   options{backend: "kaniko"},
+			expectErr: true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := newBuildBackend(c.options)
+			if err != nil && !c.expectErr {
+				t.Errorf("got unexpected error: %s", err)
+			}
+			if err == nil && c.expectErr {
+				t.Error("error expected, but no one occured")
+			}
+			if reflect.TypeOf(got) != reflect.TypeOf(c.expected) {
+				t.Errorf("newBuildBackend(): got %T, want %T", got, c.expected)
+			}
+		})
+	}
+}
+
+func Test_sensitive_tag_values_are_not_logged(t *testing.T) {
+	const secret = "s3cr3t-value"
+	t.Setenv("BUILD_TOKEN", secret)
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	o := options{
+		Config: Config{
+			Registry:     Registry{"reg"},
+			DevRegistry:  Registry{"dev-reg"},
+			DefaultPRTag: defaultPRTag,
+			TagEnv:       TagEnvConfig{Allow: tags.EnvPatterns{"BUILD_*"}, Sensitive: tags.EnvPatterns{"*_TOKEN"}},
+		},
+		logger:         zap.New(core).Sugar(),
+		context:        ".",
+		dockerfile:     "Dockerfile",
+		name:           "test-image",
+		platforms:      sets.Strings{"linux/amd64"},
+		tags:           sets.Tags{{Name: "token", Value: `{{ .Env "BUILD_TOKEN" }}`}},
+		tagsOutputFile: filepath.Join(t.TempDir(), "tags.json"),
+		silent:         true,
+		isCI:           true,
+		gitState:       prGitState,
+	}
+
+	if err := generateTags(o.logger, o); err != nil {
+		t.Fatalf("failed to generate tags: %v", err)
+	}
+	backend := &localBackend{
+		run: func(_ context.Context, _ io.Writer, name string, args ...string) error {
+			for i, arg := range args {
+				if arg == "--metadata-file" {
+					return os.WriteFile(args[i+1], []byte(`{"containerimage.digest":"sha256:abc"}`), 0644)
+				}
+			}
+			return fmt.Errorf("missing --metadata-file flag")
+		},
+	}
+	if _, err := backend.Build(context.Background(), o); err != nil {
+		t.Fatalf("failed to build image: %v", err)
+	}
+
+	if logs.Len() == 0 {
+		t.Fatalf("expected logs to be observed")
+	}
+	for _, entry := range logs.All() {
+		logged := fmt.Sprint(entry.Message, entry.ContextMap())
+		if strings.Contains(logged, secret) {
+			t.Errorf("log entry contains the sensitive value: %s", logged)
+		}
+	}
+}
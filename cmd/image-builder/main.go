@@ -6,22 +6,22 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
-	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"syscall"
 
 	adoauth "github.com/kyma-project/test-infra/pkg/azuredevops/auth"
 	adopipelines "github.com/kyma-project/test-infra/pkg/azuredevops/pipelines"
-	"github.com/kyma-project/test-infra/pkg/github/actions"
-	"github.com/kyma-project/test-infra/pkg/imagebuilder"
 	"github.com/kyma-project/test-infra/pkg/logging"
 	"github.com/kyma-project/test-infra/pkg/sets"
 	"github.com/kyma-project/test-infra/pkg/sign"
 	"github.com/kyma-project/test-infra/pkg/tags"
-	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/pipelines"
 	"go.uber.org/zap"
 	errutil "k8s.io/apimachinery/pkg/util/errors"
 )
@@ -57,6 +57,7 @@ type options struct {
 	debug                   bool
 	dryRun                  bool
 	tagsOutputFile          string
+	tagsOutputFormat        string
 	useGoInternalSAPModules bool
 	// buildReportPath is a path to the file where the build report will be saved
 	// build report will be used by SRE team to gather information about the build
@@ -66,6 +67,46 @@ type options struct {
 	adoStateOutput        bool
 	target                string
 	useRestrictedRegistry bool
+	// backend is the name of the build backend used to build the image.
+	// It overrides the build-backend value from the config file.
+	backend string
+	// localPush pushes images built with the local backend to the registry.
+	localPush bool
+	// buildManifestPath is a path to the build manifest file with multiple images to build
+	buildManifestPath string
+	// buildConcurrency is the maximum number of images from the build manifest built in parallel
+	buildConcurrency int
+	// async triggers the ADO pipeline run and exits without waiting for the run to finish
+	async bool
+	// runHandlePath is a path to the file with the handle of the ADO pipeline run started in async mode
+	runHandlePath string
+	// command is the image-builder subcommand to run, empty for the default build command
+	command string
+	// validateConfig only validates the config file, no build will be performed
+	validateConfig bool
+	// explain only prints the config effectively used for the repository and job type, no build will be performed
+	explain bool
+	// explainJobType is the job type the config is explained for
+	explainJobType string
+	// promoteSource is the image promoted by the promote command, referenced by tag or digest
+	promoteSource string
+	// promoteSign signs images promoted by the promote command
+	promoteSign bool
+	// verifyOnly only verifies signatures of images, no build will be performed
+	verifyOnly bool
+	// imagesToVerify are images whose signatures are verified in the verify-only mode
+	imagesToVerify sets.Strings
+	// output is a writer for the build logs, stdout is used if it's not set.
+	// Images from the build manifest are built in parallel, their logs are prefixed with the image name.
+	output io.Writer
+}
+
+// buildOutput returns the writer for the build logs.
+func (o options) buildOutput() io.Writer {
+	if o.output == nil {
+		return os.Stdout
+	}
+	return o.output
 }
 
 type Logger interface {
@@ -79,6 +120,8 @@ type Logger interface {
 // The function fetches various environment variables such as REPO_NAME, REPO_OWNER, JOB_TYPE, PULL_NUMBER, PULL_BASE_SHA, and PULL_PULL_SHA.
 // It validates these variables are present and sets them in the templateParameters struct.
 // It also sets other parameters from the options struct such as imageName, dockerfilePath, buildContext, exportTags, useKanikoConfigFromPR, buildArgs, and imageTags.
+// Registries chosen for the git state, see Config.targetRegistries, are set if the dev registry is configured.
+// Cache, reproducibility and log format settings are set from the config, each only if it's enabled.
 // The function validates the templateParameters and returns it along with any error that occurred during the process.
 // TODO: rename this function to indicate that it's preparing ADO pipeline parameters for oci-image-builder pipeline.
 func prepareADOTemplateParameters(options options) (adopipelines.OCIImageBuilderTemplateParams, error) {
@@ -156,6 +199,30 @@ func prepareADOTemplateParameters(options options) (adopipelines.OCIImageBuilder
 		templateParameters.SetUseRestrictedRegistry()
 	}
 
+	// Pull request and merge group images are pushed to the dev registry, other images to the registry.
+	// Without the dev registry, the pipeline pushes images to its default registry.
+	if len(options.DevRegistry) > 0 {
+		templateParameters.SetRegistries(strings.Join(options.targetRegistries(options.gitState), ","))
+	}
+
+	if options.Cache.Enabled {
+		templateParameters.SetCache(options.Cache.CacheRepo)
+		if options.Cache.CacheRunLayers {
+			templateParameters.SetCacheRunLayers()
+		}
+		if options.Cache.CacheCopyLayers {
+			templateParameters.SetCacheCopyLayers()
+		}
+	}
+
+	if options.Reproducible {
+		templateParameters.SetReproducible()
+	}
+
+	if options.LogFormat != "" {
+		templateParameters.SetLogFormat(options.LogFormat)
+	}
+
 	err := templateParameters.Validate()
 	if err != nil {
 		return nil, fmt.Errorf("failed validating ADO template parameters, err: %w", err)
@@ -165,36 +232,25 @@ func prepareADOTemplateParameters(options options) (adopipelines.OCIImageBuilder
 }
 
 // buildInADO is a function that triggers the Azure DevOps (ADO) pipeline to build an image.
-// It takes an options struct as an argument and returns an error.
+// It takes an options struct as an argument and returns the build result and an error.
 // The function fetches Azure AD Service Principal credentials from environment variables and validates they are present.
 // The function prepares the ADO pipeline parameters by calling the prepareADOTemplateParameters function.
 // It creates a new ADO client authenticated via Service Principal and prepares the ADO pipeline run arguments.
 // The function triggers the ADO build pipeline and waits for the pipeline run to finish.
 // It fetches the ADO pipeline run logs and prints them.
+// In async mode, the function doesn't wait for the pipeline run, it writes the run handle and returns.
 // The function can trigger the ADO pipeline in preview mode if the adoPreviewRun flag is set to true.
 // In preview mode, the function prints the final yaml of the ADO pipeline run.
 // Running in preview mode requires the adoPreviewRunYamlPath flag to be set to the path of the yaml file with the ADO pipeline definition.
 // This is used for pipeline syntax validation.
-// If the pipeline run fails, the function returns an error.
-// If the pipeline run is successful, the function returns nil.
+// The pipeline run result is returned as the status of the build result, together with the build report parsed from the logs.
 // TODO(dekiel): refactor this function to accept clients as parameters to make it testable with mocks.
-func buildInADO(o options) error {
+func buildInADO(ctx context.Context, o options) (*BuildResult, error) {
 	fmt.Println("Building image in ADO pipeline.")
 
-	// Getting Azure AD Service Principal credentials from environment variables when not set via flags.
-	if o.azureClientID == "" {
-		o.azureClientID = os.Getenv("AZURE_CLIENT_ID")
-	}
-	if o.azureClientSecret == "" {
-		o.azureClientSecret = os.Getenv("AZURE_CLIENT_SECRET")
-	}
-	if o.azureTenantID == "" {
-		o.azureTenantID = os.Getenv("AZURE_TENANT_ID")
-	}
-
 	if !o.dryRun {
-		if o.azureClientID == "" || o.azureClientSecret == "" || o.azureTenantID == "" {
-			return fmt.Errorf("build in ADO failed, no authentication method configured: provide --azure-client-id, --azure-client-secret and --azure-tenant-id")
+		if err := loadAzureCredentials(&o); err != nil {
+			return nil, err
 		}
 	} else {
 		fmt.Println("Running in dry-run mode. Skipping authentication check.")
@@ -204,12 +260,10 @@ func buildInADO(o options) error {
 	// Preparing ADO pipeline parameters.
 	templateParameters, err := prepareADOTemplateParameters(o)
 	if err != nil {
-		return fmt.Errorf("build in ADO failed, failed preparing ADO template parameters, err: %s", err)
+		return nil, fmt.Errorf("build in ADO failed, failed preparing ADO template parameters, err: %s", err)
 	}
 	fmt.Printf("Using TemplateParameters: %+v\n", templateParameters)
 
-	ctx := context.Background()
-
 	var opts []adopipelines.RunPipelineArgsOptions
 	// If running in preview mode, add a preview run option to the ADO pipeline run arguments.
 	if o.adoPreviewRun {
@@ -222,163 +276,88 @@ func buildInADO(o options) error {
 	// Composing ADO pipeline run arguments.
 	runPipelineArgs, err := adopipelines.NewRunPipelineArgs(templateParameters, o.AdoConfig.GetADOConfig(), opts...)
 	if err != nil {
-		return fmt.Errorf("build in ADO failed, failed creating ADO pipeline run args, err: %s", err)
+		return nil, fmt.Errorf("build in ADO failed, failed creating ADO pipeline run args, err: %s", err)
 	}
 
-	fmt.Println("Triggering ADO build pipeline")
-	var (
-		pipelineRunResult *pipelines.RunResult
-		logs              string
-		buildReport       *imagebuilder.BuildReport
-	)
-	if !o.dryRun {
-		// Creating a new ADO pipelines client.
-		spCfg := adoauth.ServicePrincipalConfig{
-			TenantID:     o.azureTenantID,
-			ClientID:     o.azureClientID,
-			ClientSecret: o.azureClientSecret,
-		}
-		cred, err := adoauth.NewServicePrincipalCredential(spCfg)
-		if err != nil {
-			return fmt.Errorf("build in ADO failed, failed creating service principal credential: %w", err)
-		}
-		provider := adoauth.NewServicePrincipalProvider(cred)
-		adoClient, err := adopipelines.NewClientWithSP(ctx, o.AdoConfig.ADOOrganizationURL, provider)
-		if err != nil {
-			return fmt.Errorf("build in ADO failed, failed creating ADO client with service principal: %w", err)
-		}
-		fmt.Println("Using Service Principal authentication.")
-
-		// Triggering ADO build pipeline.
-		pipelineRun, err := adoClient.RunPipeline(ctx, runPipelineArgs)
-		if err != nil {
-			return fmt.Errorf("build in ADO failed, failed running ADO pipeline, err: %s", err)
-		}
+	if o.dryRun {
+		return &BuildResult{Status: BuildStatusSucceeded}, nil
+	}
 
-		// If running in preview mode, print the final yaml of ADO pipeline run for provided ADO pipeline definition and return.
-		if o.adoPreviewRun {
-			if pipelineRun.FinalYaml != nil {
-				fmt.Printf("ADO pipeline preview run final yaml\n: %s", *pipelineRun.FinalYaml)
-			} else {
-				fmt.Println("ADO pipeline preview run final yaml is empty")
-			}
-			return nil
-		}
+	fmt.Println("Triggering ADO build pipeline")
+	adoClient, provider, err := newADOClient(ctx, o, o.AdoConfig.ADOOrganizationURL)
+	if err != nil {
+		return nil, err
+	}
 
-		// Fetch the ADO pipeline run result.
-		// GetRunResult function waits for the pipeline runs to finish and returns the result.
-		// TODO(dekiel) make the timeout configurable instead of hardcoding it.
-		pipelineRunResult, err = adopipelines.GetRunResult(ctx, adoClient, o.AdoConfig.GetADOConfig(), pipelineRun.Id)
-		if err != nil {
-			return fmt.Errorf("build in ADO failed, failed getting ADO pipeline run result, err: %s", err)
-		}
-		fmt.Printf("ADO pipeline run finished with status: %s\n", *pipelineRunResult)
+	// Triggering ADO build pipeline.
+	pipelineRun, err := adoClient.RunPipeline(ctx, runPipelineArgs)
+	if err != nil {
+		return nil, fmt.Errorf("build in ADO failed, failed running ADO pipeline, err: %s", err)
+	}
 
-		// Fetch the ADO pipeline run logs.
-		fmt.Println("Getting ADO pipeline run logs.")
-		adoBuildClient, err := adopipelines.NewBuildClientWithSP(ctx, o.AdoConfig.ADOOrganizationURL, provider)
-		if err != nil {
-			fmt.Printf("Can't read ADO pipeline run logs, failed creating ADO build client, err: %s", err)
+	// If running in preview mode, print the final yaml of ADO pipeline run for provided ADO pipeline definition and return.
+	if o.adoPreviewRun {
+		if pipelineRun.FinalYaml != nil {
+			fmt.Printf("ADO pipeline preview run final yaml\n: %s", *pipelineRun.FinalYaml)
 		} else {
-			logs, err = adopipelines.GetRunLogsWithBearerToken(ctx, adoBuildClient, &http.Client{}, o.AdoConfig.GetADOConfig(), pipelineRun.Id, provider)
-			if err != nil {
-				fmt.Printf("Failed read ADO pipeline run logs, err: %s", err)
-			} else {
-				fmt.Printf("ADO pipeline image build logs:\n%s", logs)
-			}
-		}
-
-		fmt.Println("Getting build report.")
-		// Parse the build report from the ADO pipeline run logs.
-		buildReport, err = imagebuilder.NewBuildReportFromLogs(logs)
-		if err != nil {
-			return fmt.Errorf("build in ADO failed, failed parsing build report from ADO pipeline run logs, err: %s", err)
+			fmt.Println("ADO pipeline preview run final yaml is empty")
 		}
-
-		o.logger.Debugw("Parsed build report from ADO logs", "buildReport", buildReport)
-	} else {
-		dryRunPipelineRunResult := pipelines.RunResult("Succeeded")
-		pipelineRunResult = &dryRunPipelineRunResult
+		return &BuildResult{Status: BuildStatusSucceeded}, nil
 	}
 
-	// TODO: Setting github outputs should happen outside buildInADO function.
-	//  buildInADO should return required data and caller should handle it.
-	// if run in github actions, set output parameters
-	if o.ciSystem == GithubActions {
-		fmt.Println("Setting GitHub outputs.")
-
-		o.logger.Debugw("Extracted built images from ADO logs", "images", buildReport.Images, "architectures", buildReport.Architectures)
-
-		imagesJSON, err := json.Marshal(buildReport.Images)
-		if err != nil {
-			return fmt.Errorf("cannot marshal list of images: %w", err)
-		}
-
-		architecturesJSON, err := json.Marshal(buildReport.Architectures)
-		if err != nil {
-			return fmt.Errorf("cannot marshal list of architectures: %w", err)
-		}
-
-		o.logger.Debugw("Set GitHub outputs", "images", string(imagesJSON), "architectures", string(architecturesJSON), "digest", buildReport.Digest, "adoResult", string(*pipelineRunResult))
-
-		err = actions.SetOutput("images", string(imagesJSON))
-		if err != nil {
-			return fmt.Errorf("cannot set images GitHub output: %w", err)
-		}
-
-		if err := actions.SetOutput("architectures", string(architecturesJSON)); err != nil {
-			return fmt.Errorf("cannot set architectures GitHub output: %w", err)
-		}
-
-		if err := actions.SetOutput("digest", buildReport.Digest); err != nil {
-			return fmt.Errorf("cannot set digest GitHub output: %w", err)
-		}
-
-		// Output full build report as JSON
-		buildReportJSON, err := json.Marshal(buildReport)
-		if err != nil {
-			return fmt.Errorf("cannot marshal build report: %w", err)
-		}
+	handle := newRunHandle(o, *pipelineRun.Id)
+	if o.async {
+		return startAsyncADORun(o, handle)
+	}
 
-		if err := actions.SetOutput("build-report", string(buildReportJSON)); err != nil {
-			return fmt.Errorf("cannot set build-report GitHub output: %w", err)
-		}
+	return waitForADORun(ctx, o, adoClient, provider, handle)
+}
 
-		err = actions.SetOutput("adoResult", string(*pipelineRunResult))
-		if err != nil {
-			return fmt.Errorf("cannot set adoResult GitHub output: %w", err)
-		}
+// loadAzureCredentials reads Azure AD Service Principal credentials from environment variables when not set via flags.
+// It returns an error if any of the credentials is missing.
+func loadAzureCredentials(o *options) error {
+	if o.azureClientID == "" {
+		o.azureClientID = os.Getenv("AZURE_CLIENT_ID")
 	}
-
-	if o.buildReportPath != "" {
-		err = imagebuilder.WriteReportToFile(buildReport, o.buildReportPath)
-		if err != nil {
-			return fmt.Errorf("failed writing build report to file: %w", err)
-		}
+	if o.azureClientSecret == "" {
+		o.azureClientSecret = os.Getenv("AZURE_CLIENT_SECRET")
+	}
+	if o.azureTenantID == "" {
+		o.azureTenantID = os.Getenv("AZURE_TENANT_ID")
 	}
 
-	// Handle the ADO pipeline run failure.
-	if *pipelineRunResult == pipelines.RunResultValues.Failed || *pipelineRunResult == pipelines.RunResultValues.Unknown {
-		return fmt.Errorf("build in ADO finished with status: %s", *pipelineRunResult)
+	if o.azureClientID == "" || o.azureClientSecret == "" || o.azureTenantID == "" {
+		return fmt.Errorf("build in ADO failed, no authentication method configured: provide --azure-client-id, --azure-client-secret and --azure-tenant-id")
 	}
 	return nil
 }
 
+// newADOClient creates a new ADO pipelines client for the organization authenticated via Service Principal.
+// It returns the token provider too, so it can be reused to authenticate other ADO requests.
+func newADOClient(ctx context.Context, o options, organizationURL string) (adopipelines.Client, adopipelines.TokenProvider, error) {
+	spCfg := adoauth.ServicePrincipalConfig{
+		TenantID:     o.azureTenantID,
+		ClientID:     o.azureClientID,
+		ClientSecret: o.azureClientSecret,
+	}
+	cred, err := adoauth.NewServicePrincipalCredential(spCfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("build in ADO failed, failed creating service principal credential: %w", err)
+	}
+	provider := adoauth.NewServicePrincipalProvider(cred)
+	adoClient, err := adopipelines.NewClientWithSP(ctx, organizationURL, provider)
+	if err != nil {
+		return nil, nil, fmt.Errorf("build in ADO failed, failed creating ADO client with service principal: %w", err)
+	}
+	fmt.Println("Using Service Principal authentication.")
+	return adoClient, provider, nil
+}
+
 // TODO: write tests for this function
 func signImages(o *options, images []string) error {
-	// use o.orgRepo as default value since someone might have loaded is as a flag
-	orgRepo := o.orgRepo
-	if o.isCI {
-		// try to extract orgRepo from Prow-based env variables
-		org := os.Getenv("REPO_OWNER")
-		repo := os.Getenv("REPO_NAME")
-		if len(org) > 0 && len(repo) > 0 {
-			// assume this is our variable since both variables are present
-			orgRepo = org + "/" + repo
-		}
-	}
-	if len(orgRepo) == 0 {
-		return fmt.Errorf("'orgRepo' cannot be empty")
+	orgRepo, err := signOrgRepo(o)
+	if err != nil {
+		return err
 	}
 	sig, err := getSignersForOrgRepo(o, orgRepo)
 	if err != nil {
@@ -395,52 +374,85 @@ func signImages(o *options, images []string) error {
 	return errutil.NewAggregate(errs)
 }
 
+// signOrgRepo returns the repository signers are selected for.
+func signOrgRepo(o *options) (string, error) {
+	// use o.orgRepo as default value since someone might have loaded is as a flag
+	orgRepo := o.orgRepo
+	if o.isCI {
+		// try to extract orgRepo from Prow-based env variables
+		org := os.Getenv("REPO_OWNER")
+		repo := os.Getenv("REPO_NAME")
+		if len(org) > 0 && len(repo) > 0 {
+			// assume this is our variable since both variables are present
+			orgRepo = org + "/" + repo
+		}
+	}
+	if len(orgRepo) == 0 {
+		return "", fmt.Errorf("'orgRepo' cannot be empty")
+	}
+	return orgRepo, nil
+}
+
 // getSignersForOrgRepo fetches all signers for a repository
 // It fetches all signers from '*' and specific org/repo combo.
 func getSignersForOrgRepo(o *options, orgRepo string) ([]sign.Signer, error) {
-	c := o.SignConfig
+	configs, ignored := enabledSignerConfigs(o.SignConfig, orgRepo, os.Getenv("JOB_TYPE"), o.isCI)
+	var names []string
+	for _, sc := range configs {
+		names = append(names, sc.Name)
+	}
+	fmt.Println("sign images using services", strings.Join(names, ", "))
+	for _, msg := range ignored {
+		fmt.Println(msg)
+	}
+
+	var signers []sign.Signer
+	for _, sc := range configs {
+		s, err := sc.Config.NewSigner()
+		if err != nil {
+			return nil, fmt.Errorf("signer init: %w", err)
+		}
+		signers = append(signers, s)
+	}
+	return signers, nil
+}
+
+// enabledSignerConfigs returns configurations of signers enabled for the repository and job type.
+// Signers enabled for '*' are enabled for all repositories.
+// It also returns messages explaining why enabled signers are ignored.
+func enabledSignerConfigs(c SignConfig, orgRepo, jobType string, isCI bool) ([]sign.SignerConfig, []string) {
 	if len(c.EnabledSigners) == 0 {
 		// no signers enabled. no need to gather signers
 		return nil, nil
 	}
 	var enabled StrList
-	jobType := os.Getenv("JOB_TYPE")
 	defaultSigners := c.EnabledSigners["*"]
 	orgRepoSigners := c.EnabledSigners[orgRepo]
 	for _, s := range append(defaultSigners, orgRepoSigners...) {
 		enabled.Add(s)
 	}
-	fmt.Println("sign images using services", strings.Join(enabled.List(), ", "))
-	var signers []sign.Signer
+
+	var (
+		configs []sign.SignerConfig
+		ignored []string
+	)
 	for _, sc := range c.Signers {
-		if enabled.Has(sc.Name) {
-			// if signerConfig doesn't contain any jobTypes, it should be considered enabled by default
-			if len(sc.JobType) > 0 && !o.isCI {
-				fmt.Println("signer", sc.Name, "ignored, because image-builder is not running in CI mode and contains 'job-type' field defined")
-				continue
-			}
-			if len(jobType) > 0 && len(sc.JobType) > 0 && o.isCI {
-				var has bool
-				for _, t := range sc.JobType {
-					if t == jobType {
-						has = true
-						break
-					}
-				}
-				if !has {
-					// ignore signer if the jobType doesn't contain specific job type
-					fmt.Println("signer", sc.Name, "ignored, because is not enabled for a CI job of type:", jobType)
-					continue
-				}
-			}
-			s, err := sc.Config.NewSigner()
-			if err != nil {
-				return nil, fmt.Errorf("signer init: %w", err)
-			}
-			signers = append(signers, s)
+		if !enabled.Has(sc.Name) {
+			continue
+		}
+		// if signerConfig doesn't contain any jobTypes, it should be considered enabled by default
+		if len(sc.JobType) > 0 && !isCI {
+			ignored = append(ignored, fmt.Sprintf("signer %s ignored, because image-builder is not running in CI mode and contains 'job-type' field defined", sc.Name))
+			continue
 		}
+		if len(jobType) > 0 && len(sc.JobType) > 0 && isCI && !slices.Contains(sc.JobType, jobType) {
+			// ignore signer if the jobType doesn't contain specific job type
+			ignored = append(ignored, fmt.Sprintf("signer %s ignored, because is not enabled for a CI job of type: %s", sc.Name, jobType))
+			continue
+		}
+		configs = append(configs, sc)
 	}
-	return signers, nil
+	return configs, ignored
 }
 
 // StrList implements list of strings as a map
@@ -476,7 +488,8 @@ func (l *StrList) List() []string {
 	return n
 }
 
-func getTags(logger Logger, pr, sha string, templates []tags.Tag) ([]tags.Tag, error) {
+// getTags parses tag templates. Tagger options passed in opts are applied after the PR number and commit SHA options.
+func getTags(logger Logger, pr, sha string, templates []tags.Tag, opts ...tags.TagOption) ([]tags.Tag, error) {
 	logger.Debugw("started building tags", "pr_number", pr, "commit_sha", sha, "templates", templates)
 
 	logger.Debugw("building tagger options")
@@ -489,6 +502,7 @@ func getTags(logger Logger, pr, sha string, templates []tags.Tag) ([]tags.Tag, e
 		taggerOptions = append(taggerOptions, tags.CommitSHA(sha))
 		logger.Debugw("commit sha is set, adding tagger option", "commit_sha", sha)
 	}
+	taggerOptions = append(taggerOptions, opts...)
 
 	taggerOptions = append(taggerOptions, tags.WithLogger(logger))
 	logger.Debugw("added logger to tagger options")
@@ -502,7 +516,11 @@ func getTags(logger Logger, pr, sha string, templates []tags.Tag) ([]tags.Tag, e
 	if err != nil {
 		return nil, fmt.Errorf("build tag: %w", err)
 	}
-	logger.Debugw("parsed tags successfully", "tags", p)
+	logger.Debugw("parsed tags successfully", "tags", tagNames(p))
+	// Skipped tags are expected, e.g. latest is used only on the main branch, so they are reported, not failed
+	for _, skipped := range tagger.Skipped() {
+		logger.Infow("Skipped tag, its condition is not met", "tag", skipped.Tag.Name, "reason", skipped.Reason)
+	}
 
 	return p, nil
 }
@@ -511,14 +529,83 @@ func getTags(logger Logger, pr, sha string, templates []tags.Tag) ([]tags.Tag, e
 func validateOptions(o options) error {
 	var errs []error
 
+	if o.command == PromoteCommand {
+		// promote command copies the existing image, it doesn't need the build context
+		if o.promoteSource == "" {
+			errs = append(errs, fmt.Errorf("flag '--source' is missing, please provide the image to promote"))
+		}
+		if o.name == "" {
+			errs = append(errs, fmt.Errorf("flag '--name' is missing"))
+		}
+		if o.configPath == "" {
+			errs = append(errs, fmt.Errorf("'--config' flag is missing or has empty value, please provide the path to valid 'config.yaml' file"))
+		}
+		return errutil.NewAggregate(errs)
+	}
+
+	if o.command != "" {
+		// wait and status commands read image data from the run handle
+		if o.runHandlePath == "" {
+			errs = append(errs, fmt.Errorf("flag '--run-handle-file' is missing, please provide the path to the run handle file written in async mode"))
+		}
+		if o.configPath == "" {
+			errs = append(errs, fmt.Errorf("'--config' flag is missing or has empty value, please provide the path to valid 'config.yaml' file"))
+		}
+		return errutil.NewAggregate(errs)
+	}
+
+	if o.verifyOnly {
+		// verify-only mode only reads signatures of existing images
+		if len(o.imagesToVerify) == 0 {
+			errs = append(errs, fmt.Errorf("flag '--images-to-verify' is missing, please provide at least one image to verify"))
+		}
+		if o.signOnly {
+			errs = append(errs, fmt.Errorf("flag '--verify-only' can't be used together with '--sign-only'"))
+		}
+		if o.configPath == "" {
+			errs = append(errs, fmt.Errorf("'--config' flag is missing or has empty value, please provide the path to valid 'config.yaml' file"))
+		}
+		return errutil.NewAggregate(errs)
+	}
+	if len(o.imagesToVerify) > 0 {
+		errs = append(errs, fmt.Errorf("flag '--verify-only' is missing or has false value, please set it to true when using '--images-to-verify' flag"))
+	}
+
+	if o.validateConfig || o.explain {
+		// config modes only read the config file
+		if o.configPath == "" {
+			errs = append(errs, fmt.Errorf("'--config' flag is missing or has empty value, please provide the path to valid 'config.yaml' file"))
+		}
+		if o.validateConfig && o.explain {
+			errs = append(errs, fmt.Errorf("flag '--validate-config' can't be used together with '--explain'"))
+		}
+		// job types are keys of tag policies, so policies of git tag builds can be explained too
+		if o.explain && !slices.Contains(tagPolicyKeys, o.explainJobType) {
+			errs = append(errs, fmt.Errorf("flag '--job-type' has unsupported value %s, supported values: %v", o.explainJobType, tagPolicyKeys))
+		}
+		return errutil.NewAggregate(errs)
+	}
+
 	if o.context == "" {
 		errs = append(errs, fmt.Errorf("flag '--context' is missing"))
 	}
 
-	if o.name == "" {
+	if o.async && o.buildManifestPath != "" {
+		errs = append(errs, fmt.Errorf("flag '--async' can't be used together with '--build-manifest'"))
+	}
+
+	if o.name == "" && o.buildManifestPath == "" {
 		errs = append(errs, fmt.Errorf("flag '--name' is missing"))
 	}
 
+	if o.name != "" && o.buildManifestPath != "" {
+		errs = append(errs, fmt.Errorf("flag '--name' can't be used together with '--build-manifest', provide image names in the build manifest"))
+	}
+
+	if o.buildManifestPath != "" && o.buildConcurrency < 1 {
+		errs = append(errs, fmt.Errorf("flag '--build-concurrency' must be greater than 0"))
+	}
+
 	if o.dockerfile == "" {
 		errs = append(errs, fmt.Errorf("flag '--dockerfile' is missing"))
 	}
@@ -551,7 +638,7 @@ func (o *options) gatherOptions(flagSet *flag.FlagSet) *flag.FlagSet {
 	flagSet.StringVar(&o.context, "context", ".", "Path to build directory context")
 	flagSet.StringVar(&o.name, "name", "", "name of the image to be built")
 	flagSet.StringVar(&o.dockerfile, "dockerfile", "dockerfile", "Path to dockerfile file relative to context")
-	flagSet.StringVar(&o.logDir, "log-dir", "/logs/artifacts", "Path to logs directory where GCB logs will be stored")
+	flagSet.StringVar(&o.logDir, "log-dir", "/logs/artifacts", "Path to logs directory where logs of all ADO pipeline run steps will be stored")
 	flagSet.BoolVar(&o.debug, "debug", false, "Enable debug logging")
 	flagSet.BoolVar(&o.dryRun, "dry-run", false, "Do not build the image, only print a ADO API call pipeline parameters")
 	// TODO: What is expected value repo only or org/repo? How this flag influence an image builder behaviour?
@@ -563,6 +650,8 @@ func (o *options) gatherOptions(flagSet *flag.FlagSet) *flag.FlagSet {
 	flagSet.BoolVar(&o.exportTags, "export-tags", false, "Export parsed tags as build-args into dockerfile. Each tag will have format TAG_x, where x is the tag name passed along with the tag")
 	flagSet.BoolVar(&o.signOnly, "sign-only", false, "Only sign the image, do not build it")
 	flagSet.Var(&o.imagesToSign, "images-to-sign", "Comma-separated list of images to sign. Only used when sign-only flag is set")
+	flagSet.BoolVar(&o.verifyOnly, "verify-only", false, "Only verify that images are signed by all required signers, do not build them")
+	flagSet.Var(&o.imagesToVerify, "images-to-verify", "Comma-separated list of images to verify. Only used when verify-only flag is set")
 	flagSet.BoolVar(&o.adoPreviewRun, "ado-preview-run", false, "Trigger ADO pipeline in preview mode")
 	flagSet.StringVar(&o.adoPreviewRunYamlPath, "ado-preview-run-yaml-path", "", "Path to yaml file with ADO pipeline definition to be used in preview mode")
 	flagSet.BoolVar(&o.parseTagsOnly, "parse-tags-only", false, "Only parse tags and print them to stdout")
@@ -570,12 +659,24 @@ func (o *options) gatherOptions(flagSet *flag.FlagSet) *flag.FlagSet {
 	flagSet.StringVar(&o.azureClientID, "azure-client-id", "", "Azure AD Application (client) ID used to authenticate against Azure DevOps API")
 	flagSet.StringVar(&o.azureClientSecret, "azure-client-secret", "", "Azure AD Application client secret used to authenticate against Azure DevOps API")
 	flagSet.StringVar(&o.azureTenantID, "azure-tenant-id", "", "Azure AD Tenant ID used to authenticate against Azure DevOps API")
-	flagSet.StringVar(&o.tagsOutputFile, "tags-output-file", "/generated-tags.json", "Path to file where generated tags will be written as JSON")
+	flagSet.StringVar(&o.tagsOutputFile, "tags-output-file", "/generated-tags.json", "Path to file where generated tags will be written in the format set with --tags-output-format")
+	flagSet.StringVar(&o.tagsOutputFormat, "tags-output-format", TagsFormatJSON, fmt.Sprintf("Format of tags generated with --parse-tags-only, one of: %s", strings.Join(supportedTagsFormats, ", ")))
 	flagSet.BoolVar(&o.useGoInternalSAPModules, "use-go-internal-sap-modules", false, "Allow access to Go internal modules in ADO backend")
 	flagSet.StringVar(&o.buildReportPath, "build-report-path", "", "Path to file where build report will be written as JSON")
 	flagSet.BoolVar(&o.adoStateOutput, "ado-state-output", false, "Set output variables with result of image-buidler exececution")
 	flagSet.StringVar(&o.target, "target", "", "Specify which build stage in the Dockerfile to use as the target")
 	flagSet.BoolVar(&o.useRestrictedRegistry, "use-restricted-registry", false, "Enable building images using Chainguard restricted base images")
+	flagSet.StringVar(&o.backend, "backend", "", "Build backend used to build the image, one of: ado, local. Overrides build-backend from the config file (default: ado)")
+	flagSet.BoolVar(&o.localPush, "local-push", false, "Push images built with the local backend to the registry")
+	flagSet.StringVar(&o.buildManifestPath, "build-manifest", "", "Path to YAML file with a list of images to build in a single run")
+	flagSet.IntVar(&o.buildConcurrency, "build-concurrency", 4, "Maximum number of images from the build manifest built in parallel")
+	flagSet.BoolVar(&o.async, "async", false, "Trigger the ADO pipeline run and exit without waiting for it to finish. Use the wait or status command to get the result")
+	flagSet.BoolVar(&o.validateConfig, "validate-config", false, "Only validate the config file and report all found problems, do not build the image")
+	flagSet.BoolVar(&o.explain, "explain", false, "Only print the config effectively used for the repository provided with --repo and the job type provided with --job-type, do not build the image")
+	flagSet.StringVar(&o.explainJobType, "job-type", "postsubmit", "Job type the config is explained for with the --explain flag")
+	flagSet.StringVar(&o.runHandlePath, "run-handle-file", "", "Path to file where the handle of the ADO pipeline run started in async mode is written to or read from by the wait and status commands")
+	flagSet.StringVar(&o.promoteSource, "source", "", "Image promoted by the promote command, referenced by tag or digest, e.g. europe-docker.pkg.dev/kyma-project/dev/image@sha256:...")
+	flagSet.BoolVar(&o.promoteSign, "sign", false, "Sign images promoted by the promote command with signers enabled for the repository")
 
 	return flagSet
 }
@@ -584,7 +685,12 @@ func main() {
 	flagSet := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	o := options{isCI: os.Getenv("CI") == "true"}
 	o.gatherOptions(flagSet)
-	if err := flagSet.Parse(os.Args[1:]); err != nil {
+	args := os.Args[1:]
+	// wait, status and promote commands are passed as the first argument, followed by flags
+	if len(args) > 0 && (args[0] == WaitCommand || args[0] == StatusCommand || args[0] == PromoteCommand) {
+		o.command, args = args[0], args[1:]
+	}
+	if err := flagSet.Parse(args); err != nil {
 		log.Fatalf("Failed to parse flags: %s", err)
 	}
 
@@ -602,15 +708,53 @@ func main() {
 	}
 	o.logger = zapLogger.Sugar()
 
+	// Interrupting image-builder cancels the context, so the ADO pipeline run started by it is cancelled too.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// validate if options provided by flags and config file are fine
+	if err := validateOptions(o); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	c, err := os.ReadFile(o.configPath)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if err := o.ParseConfig(c); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if o.validateConfig {
+		if err := runValidateConfig(o, os.Stdout); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if o.explain {
+		if err := runExplainConfig(o, os.Stdout); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	// If running inside some CI system, determine which system is used
+	// The config is read first, because it may define the generic CI system
 	if o.isCI {
-		o.ciSystem, err = DetermineUsedCISystem()
+		o.ciSystem, err = DetermineUsedCISystem(o.GenericCI)
 		if err != nil {
 			o.logger.Errorw("Failed to determine current ci system", "error", err)
 			os.Exit(1)
 		}
 
-		o.gitState, err = LoadGitStateConfig(o.logger, o.ciSystem)
+		o.gitState, err = LoadGitStateConfig(o.logger, o.ciSystem, o.GenericCI)
 		if err != nil {
 			o.logger.Errorw("Failed to load current git state", "error", err)
 			os.Exit(1)
@@ -619,21 +763,25 @@ func main() {
 		o.logger.Debugw("Git state loaded", "gitState", o.gitState)
 	}
 
-	// validate if options provided by flags and config file are fine
-	if err := validateOptions(o); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
-
-	c, err := os.ReadFile(o.configPath)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	if o.command == PromoteCommand {
+		err = runBuild(ctx, o, newPromoteBackend())
+		if err != nil {
+			o.logger.Errorw("Image promotion failed", "error", err)
+			os.Exit(1)
+		}
+		fmt.Println("Job's done.")
+		os.Exit(0)
 	}
 
-	if err := o.ParseConfig(c); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	if o.command != "" {
+		logger := o.logger.With("command", o.command)
+		err = runHandleCommand(ctx, o)
+		if err != nil {
+			logger.Errorw("Image build failed", "error", err)
+			os.Exit(1)
+		}
+		fmt.Println("Job's done.")
+		os.Exit(0)
 	}
 
 	if o.signOnly {
@@ -653,6 +801,18 @@ func main() {
 		os.Exit(0)
 	}
 
+	if o.verifyOnly {
+		err = runVerifyImages(&o, os.Stdout)
+		if o.adoStateOutput {
+			adopipelines.SetVariable("verification_success", err == nil, false, true)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	if o.parseTagsOnly {
 		logger := o.logger.With("command", "parse-tags-only")
 		logger.Infow("Parsing tags")
@@ -664,7 +824,28 @@ func main() {
 		logger.Infow("Tags parsed successfully")
 		os.Exit(0)
 	}
-	err = buildInADO(o)
+	backend, err := newBuildBackend(o)
+	if err != nil {
+		o.logger.Errorw("Failed to select build backend", "error", err)
+		os.Exit(1)
+	}
+
+	if o.buildManifestPath != "" {
+		manifest, err := LoadBuildManifest(o.buildManifestPath)
+		if err != nil {
+			o.logger.Errorw("Failed to load build manifest", "error", err)
+			os.Exit(1)
+		}
+		err = runManifestBuild(ctx, o, backend, manifest)
+		if err != nil {
+			o.logger.Errorw("Image build failed", "error", err, "JobType", o.gitState.JobType)
+			os.Exit(1)
+		}
+		fmt.Println("Job's done.")
+		os.Exit(0)
+	}
+
+	err = runBuild(ctx, o, backend)
 	if err != nil {
 		o.logger.Errorw("Image build failed", "error", err, "JobType", o.gitState.JobType)
 		os.Exit(1)
@@ -675,6 +856,12 @@ func main() {
 
 func generateTags(logger Logger, o options) error {
 	logger.Infow("starting tag generation")
+	if o.tagsOutputFormat == "" {
+		o.tagsOutputFormat = TagsFormatJSON
+	}
+	if err := validateTagsOutputFormat(o); err != nil {
+		return err
+	}
 	logger.Debugw("getting the absolute path to the Dockerfile directory")
 	// Get the absolute path to the dockerfile directory.
 	dockerfileDirPath, err := getDockerfileDirPath(logger, o)
@@ -688,21 +875,10 @@ func generateTags(logger Logger, o options) error {
 	if err != nil {
 		return fmt.Errorf("failed to parse tags from options: %w", err)
 	}
-	logger.Infow("tags parsed successfully", "parsedTags", parsedTags)
-	logger.Debugw("converting parsed tags to JSON")
-	jsonTags, err := tagsAsJSON(parsedTags)
-	if err != nil {
-		return fmt.Errorf("failed generating tags json representation: %w", err)
-	}
-	logger.Debugw("successfully generated image tags in JSON format", "tags", jsonTags)
-	// Write tags to a file.
-	if o.tagsOutputFile != "" {
-		logger.Debugw("tags output file provided", "tagsOutputFile", o.tagsOutputFile)
-		err = writeOutputFile(logger, o.tagsOutputFile, jsonTags)
-		if err != nil {
-			return fmt.Errorf("failed to write tags to file: %w", err)
-		}
-		logger.Infow("tags successfully written to file", "tagsOutputFile", o.tagsOutputFile, "generatedTags", jsonTags)
+	logger.Infow("tags parsed successfully", "tags", tagNames(parsedTags))
+	// Write tags to a file or CI outputs.
+	if err := writeTagsOutput(logger, o, parsedTags); err != nil {
+		return fmt.Errorf("failed generating tags output in %s format: %w", o.tagsOutputFormat, err)
 	}
 	return nil
 }
@@ -726,13 +902,8 @@ func tagsAsJSON(parsedTags []tags.Tag) ([]byte, error) {
 	return jsonTags, err
 }
 
-func parseTags(logger Logger, o options) ([]tags.Tag, error) {
-	logger.Debugw("starting to parse tags")
-	var (
-		pr  string
-		sha string
-	)
-
+// tagTemplateContext returns the PR number, the commit SHA and tagger options making the git and image context available in tag templates.
+func tagTemplateContext(logger Logger, o options) (pr, sha string, taggerOptions []tags.TagOption) {
 	logger.Debugw("reading git state for event type")
 	if !o.gitState.isPullRequest && o.gitState.BaseCommitSHA != "" {
 		sha = o.gitState.BaseCommitSHA
@@ -741,15 +912,28 @@ func parseTags(logger Logger, o options) ([]tags.Tag, error) {
 	if o.gitState.isPullRequest && o.gitState.PullRequestNumber > 0 {
 		pr = fmt.Sprint(o.gitState.PullRequestNumber)
 		logger.Debugw("Running for pull request event, PR number found", "pr-number", pr)
-		if len(o.AdditionalPRTag.Value) > 0 {
-			o.tags = append(o.tags, o.AdditionalPRTag)
-			logger.Debugw("additional PR tag appended", "tag", o.AdditionalPRTag.Value)
-		}
 	}
 	if o.gitState.JobType == "merge_group" && o.gitState.PullHeadCommitSHA != "" {
 		sha = o.gitState.PullHeadCommitSHA
 		logger.Debugw("running for merge_group event, pull head commit SHA found", "sha", sha)
 	}
+	// Git and image context is available in tag templates as typed fields
+	taggerOptions = []tags.TagOption{tags.WithGitState(o.gitState.tagsGitState()), tags.Platforms(buildPlatforms(o))}
+	taggerOptions = append(taggerOptions, o.TagEnv.taggerOptions()...)
+	if len(o.name) > 0 {
+		taggerOptions = append(taggerOptions, tags.ImageName(o.name))
+	}
+	// Describe data is read from the local repository of the build context, only if a template uses it
+	if len(o.context) > 0 {
+		taggerOptions = append(taggerOptions, tags.GitRepository(o.context))
+	}
+	return pr, sha, taggerOptions
+}
+
+// parseTags parses tags provided with flags together with default and tag policy tags of the git state.
+func parseTags(logger Logger, o options) ([]tags.Tag, error) {
+	logger.Debugw("starting to parse tags")
+	pr, sha, taggerOptions := tagTemplateContext(logger, o)
 
 	// TODO (dekiel): Tags provided as base64 encoded string should be parsed and added to the tags list when parsing flags.
 	//   This way all tags are available in the tags list from thr very beginning of execution and can be used in any process.
@@ -778,43 +962,23 @@ func parseTags(logger Logger, o options) ([]tags.Tag, error) {
 		logger.Debugw("no base64 encoded tags provided")
 	}
 
-	logger.Debugw("getting default tag")
-	defaultTag, err := getDefaultTag(logger, o)
+	logger.Debugw("getting tags from tag policy")
+	policyTags, err := getPolicyTags(logger, o)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve default tag, error: %w", err)
+		return nil, fmt.Errorf("failed to retrieve tags from tag policy, error: %w", err)
 	}
-	logger.Debugw("default tag retrieved", "defaultTag", defaultTag)
+	logger.Debugw("tag policy tags retrieved", "policyTags", policyTags)
 
 	logger.Debugw("parsing tags")
-	parsedTags, err := getTags(logger, pr, sha, append(o.tags, defaultTag))
+	parsedTags, err := getTags(logger, pr, sha, append(o.tags, policyTags...), taggerOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse tags: %w", err)
 	}
-	logger.Debugw("tags parsed successfully", "parsedTags", parsedTags)
+	logger.Debugw("tags parsed successfully", "tags", tagNames(parsedTags))
 
 	return parsedTags, nil
 }
 
-// getDefaultTag returns the default tag based on the read git state.
-// The function provide default tag for pull request or commit.
-// The default tag is read from the provided 'options' struct.
-func getDefaultTag(logger Logger, o options) (tags.Tag, error) {
-	logger.Debugw("reading gitstate data")
-	if o.gitState.JobType == "merge_group" {
-		logger.Debugw("merge_group event, returning default merge group tag")
-		return o.DefaultMergeGroupTag, nil
-	}
-	if o.gitState.isPullRequest && o.gitState.PullRequestNumber > 0 {
-		logger.Debugw("pull request number provided, returning default pr tag")
-		return o.DefaultPRTag, nil
-	}
-	if len(o.gitState.BaseCommitSHA) > 0 {
-		o.logger.Debugw("commit sha provided, returning default commit tag")
-		return o.DefaultCommitTag, nil
-	}
-	return tags.Tag{}, fmt.Errorf("could not determine default tag, no pr number or commit sha provided")
-}
-
 func getDockerfileDirPath(logger Logger, o options) (string, error) {
 	logger.Debugw("starting to get Dockerfile directory path", "dockerfile", o.dockerfile, "context", o.context)
 	// Get the absolute path to the build context directory.
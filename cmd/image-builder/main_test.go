@@ -46,6 +46,11 @@ var (
 		BaseCommitSHA: "abcdef123456",
 		isPullRequest: false,
 	}
+	tagGitState = GitStateConfig{
+		BaseCommitSHA: "abcdef123456",
+		RefType:       RefTypeTag,
+		TagName:       "v1.2.3",
+	}
 )
 
 var _ = Describe("Image Builder", func() {
@@ -171,18 +176,32 @@ var _ = Describe("Image Builder", func() {
 				gitState:       prGitState,
 				dockerfile:     "Dockerfile",
 				context:        ".",
-				tagsBase64:     base64.StdEncoding.EncodeToString([]byte("latest,version={{ .ShortSHA }}")),
+				tagsBase64:     base64.StdEncoding.EncodeToString([]byte("latest,version=pr{{ .PRNumber }}")),
 				tagsOutputFile: "tags.json",
 			},
 			fstest.MapFS{"Dockerfile": {}},
 			tagsToJSON([]tags.Tag{
 				{Name: "latest", Value: "latest"},
-				{Name: "version", Value: ""},
+				{Name: "version", Value: "pr5"},
 				expectedDefaultPRTag(prGitState.PullRequestNumber),
 			}),
 			false,
 		),
 
+		Entry("Failure - Base64 tag resolved to empty value",
+			options{
+				Config:         buildConfig,
+				gitState:       prGitState,
+				dockerfile:     "Dockerfile",
+				context:        ".",
+				tagsBase64:     base64.StdEncoding.EncodeToString([]byte("version={{ .ShortSHA }}")),
+				tagsOutputFile: "tags.json",
+			},
+			fstest.MapFS{"Dockerfile": {}},
+			"",
+			true,
+		),
+
 		Entry("Edge Case - No output file specified",
 			options{
 				Config:         buildConfig,
@@ -328,6 +347,119 @@ var _ = Describe("Image Builder", func() {
 			},
 			true,
 		),
+		Entry(
+			"build manifest without name",
+			options{
+				context:           "directory/",
+				dockerfile:        "dockerfile",
+				configPath:        "config.yaml",
+				buildManifestPath: "manifest.yaml",
+				buildConcurrency:  4,
+			},
+			false,
+		),
+		Entry(
+			"build manifest with name",
+			options{
+				context:           "directory/",
+				name:              "test-image",
+				dockerfile:        "dockerfile",
+				configPath:        "config.yaml",
+				buildManifestPath: "manifest.yaml",
+				buildConcurrency:  4,
+			},
+			true,
+		),
+		Entry(
+			"validate config without build flags",
+			options{
+				configPath:     "config.yaml",
+				validateConfig: true,
+			},
+			false,
+		),
+		Entry(
+			"explain with unsupported job type",
+			options{
+				configPath:     "config.yaml",
+				explain:        true,
+				explainJobType: "nightly",
+			},
+			true,
+		),
+		Entry(
+			"explain tag policies of git tag builds",
+			options{
+				configPath:     "config.yaml",
+				explain:        true,
+				explainJobType: tagPolicyKeyTag,
+			},
+			false,
+		),
+		Entry(
+			"wait command with run handle file",
+			options{
+				configPath:    "config.yaml",
+				command:       WaitCommand,
+				runHandlePath: "handle.json",
+			},
+			false,
+		),
+		Entry(
+			"status command without run handle file",
+			options{
+				configPath: "config.yaml",
+				command:    StatusCommand,
+			},
+			true,
+		),
+		Entry(
+			"verify-only mode with images to verify",
+			options{
+				configPath:     "config.yaml",
+				verifyOnly:     true,
+				imagesToVerify: sets.Strings{"europe-docker.pkg.dev/kyma-project/prod/test-image:1.0.0"},
+			},
+			false,
+		),
+		Entry(
+			"verify-only mode without images to verify",
+			options{
+				configPath: "config.yaml",
+				verifyOnly: true,
+			},
+			true,
+		),
+		Entry(
+			"images to verify without verify-only mode",
+			options{
+				context:        "directory/",
+				name:           "test-image",
+				dockerfile:     "Dockerfile",
+				configPath:     "config.yaml",
+				imagesToVerify: sets.Strings{"europe-docker.pkg.dev/kyma-project/prod/test-image:1.0.0"},
+			},
+			true,
+		),
+		Entry(
+			"promote command with source image",
+			options{
+				configPath:    "config.yaml",
+				command:       PromoteCommand,
+				name:          "test-image",
+				promoteSource: "europe-docker.pkg.dev/kyma-project/dev/test-image:PR-123",
+			},
+			false,
+		),
+		Entry(
+			"promote command without source image",
+			options{
+				configPath: "config.yaml",
+				command:    PromoteCommand,
+				name:       "test-image",
+			},
+			true,
+		),
 	)
 
 	DescribeTable("Test Flags",
@@ -350,11 +482,14 @@ var _ = Describe("Image Builder", func() {
 				"--unknown-flag=asdasd",
 			},
 			options{
-				context:        ".",
-				configPath:     "/config/image-builder-config.yaml",
-				dockerfile:     "dockerfile",
-				logDir:         "/logs/artifacts",
-				tagsOutputFile: "/generated-tags.json",
+				context:          ".",
+				configPath:       "/config/image-builder-config.yaml",
+				dockerfile:       "dockerfile",
+				logDir:           "/logs/artifacts",
+				tagsOutputFile:   "/generated-tags.json",
+				tagsOutputFormat: "json",
+				buildConcurrency: 4,
+				explainJobType:   "postsubmit",
 			},
 			true,
 		),
@@ -376,13 +511,16 @@ var _ = Describe("Image Builder", func() {
 					{Name: "latest", Value: "latest"},
 					{Name: "cookie", Value: "cookie"},
 				},
-				context:        "prow/build",
-				configPath:     "config.yaml",
-				dockerfile:     "dockerfile",
-				logDir:         "prow/logs",
-				orgRepo:        "kyma-project/test-infra",
-				silent:         true,
-				tagsOutputFile: "/generated-tags.json",
+				context:          "prow/build",
+				configPath:       "config.yaml",
+				dockerfile:       "dockerfile",
+				logDir:           "prow/logs",
+				orgRepo:          "kyma-project/test-infra",
+				silent:           true,
+				tagsOutputFile:   "/generated-tags.json",
+				tagsOutputFormat: "json",
+				buildConcurrency: 4,
+				explainJobType:   "postsubmit",
 			},
 			false,
 		),
@@ -391,12 +529,15 @@ var _ = Describe("Image Builder", func() {
 				"--export-tags",
 			},
 			options{
-				context:        ".",
-				configPath:     "/config/image-builder-config.yaml",
-				dockerfile:     "dockerfile",
-				logDir:         "/logs/artifacts",
-				exportTags:     true,
-				tagsOutputFile: "/generated-tags.json",
+				context:          ".",
+				configPath:       "/config/image-builder-config.yaml",
+				dockerfile:       "dockerfile",
+				logDir:           "/logs/artifacts",
+				exportTags:       true,
+				tagsOutputFile:   "/generated-tags.json",
+				tagsOutputFormat: "json",
+				buildConcurrency: 4,
+				explainJobType:   "postsubmit",
 			},
 			false,
 		),
@@ -414,7 +555,10 @@ var _ = Describe("Image Builder", func() {
 					tags.Tag{Name: "BIN", Value: "test"},
 					tags.Tag{Name: "BIN2", Value: "test2"},
 				},
-				tagsOutputFile: "/generated-tags.json",
+				tagsOutputFile:   "/generated-tags.json",
+				tagsOutputFormat: "json",
+				buildConcurrency: 4,
+				explainJobType:   "postsubmit",
 			},
 			false,
 		),
@@ -423,12 +567,15 @@ var _ = Describe("Image Builder", func() {
 				"--platform=linux/amd64",
 			},
 			options{
-				context:        ".",
-				configPath:     "/config/image-builder-config.yaml",
-				dockerfile:     "dockerfile",
-				logDir:         "/logs/artifacts",
-				tagsOutputFile: "/generated-tags.json",
-				platforms:      []string{"linux/amd64"},
+				context:          ".",
+				configPath:       "/config/image-builder-config.yaml",
+				dockerfile:       "dockerfile",
+				logDir:           "/logs/artifacts",
+				tagsOutputFile:   "/generated-tags.json",
+				tagsOutputFormat: "json",
+				buildConcurrency: 4,
+				explainJobType:   "postsubmit",
+				platforms:        []string{"linux/amd64"},
 			},
 			false,
 		),
@@ -437,12 +584,15 @@ var _ = Describe("Image Builder", func() {
 				"--target=build",
 			},
 			options{
-				context:        ".",
-				configPath:     "/config/image-builder-config.yaml",
-				dockerfile:     "dockerfile",
-				logDir:         "/logs/artifacts",
-				tagsOutputFile: "/generated-tags.json",
-				target:         "build",
+				context:          ".",
+				configPath:       "/config/image-builder-config.yaml",
+				dockerfile:       "dockerfile",
+				logDir:           "/logs/artifacts",
+				tagsOutputFile:   "/generated-tags.json",
+				tagsOutputFormat: "json",
+				buildConcurrency: 4,
+				explainJobType:   "postsubmit",
+				target:           "build",
 			},
 			false,
 		),
@@ -551,6 +701,92 @@ var _ = Describe("Image Builder", func() {
 			},
 			false,
 		),
+		Entry("Pull request with dev registry, cache, reproducible and log format from config",
+			options{
+				Config: Config{
+					Registry:     Registry{"europe-docker.pkg.dev/kyma-project/prod"},
+					DevRegistry:  Registry{"europe-docker.pkg.dev/kyma-project/dev", "europe-docker.pkg.dev/kyma-project/dev-mirror"},
+					Cache:        CacheConfig{Enabled: true, CacheRunLayers: true, CacheRepo: "europe-docker.pkg.dev/kyma-project/cache"},
+					Reproducible: true,
+					LogFormat:    "json",
+				},
+				gitState: GitStateConfig{
+					JobType:           "presubmit",
+					PullRequestNumber: 5,
+					PullHeadCommitSHA: "def456",
+					isPullRequest:     true,
+				},
+			},
+			pipelines.OCIImageBuilderTemplateParams{
+				"Context":        "",
+				"Dockerfile":     "",
+				"ExportTags":     "false",
+				"JobType":        "presubmit",
+				"Name":           "",
+				"PullBaseSHA":    "",
+				"PullNumber":     "5",
+				"PullPullSHA":    "def456",
+				"RepoName":       "",
+				"RepoOwner":      "",
+				"Platforms":      "linux/amd64,linux/arm64",
+				"Registries":     "europe-docker.pkg.dev/kyma-project/dev,europe-docker.pkg.dev/kyma-project/dev-mirror",
+				"Cache":          "true",
+				"CacheRepo":      "europe-docker.pkg.dev/kyma-project/cache",
+				"CacheRunLayers": "true",
+				"Reproducible":   "true",
+				"LogFormat":      "json",
+			},
+			false,
+		),
+		Entry("Registry without dev registry and cache without cached layers",
+			options{
+				Config: Config{
+					Registry: Registry{"europe-docker.pkg.dev/kyma-project/prod"},
+					Cache:    CacheConfig{Enabled: true, CacheRepo: "europe-docker.pkg.dev/kyma-project/cache"},
+				},
+				gitState: GitStateConfig{
+					JobType: "postsubmit",
+				},
+			},
+			pipelines.OCIImageBuilderTemplateParams{
+				"Context":     "",
+				"Dockerfile":  "",
+				"ExportTags":  "false",
+				"JobType":     "postsubmit",
+				"Name":        "",
+				"PullBaseSHA": "",
+				"RepoName":    "",
+				"RepoOwner":   "",
+				"Platforms":   "linux/amd64,linux/arm64",
+				"Cache":       "true",
+				"CacheRepo":   "europe-docker.pkg.dev/kyma-project/cache",
+			},
+			false,
+		),
+		Entry("Postsubmit with dev registry uses registry",
+			options{
+				Config: Config{
+					Registry:    Registry{"europe-docker.pkg.dev/kyma-project/prod"},
+					DevRegistry: Registry{"europe-docker.pkg.dev/kyma-project/dev"},
+				},
+				gitState: GitStateConfig{
+					JobType: "postsubmit",
+				},
+			},
+			pipelines.OCIImageBuilderTemplateParams{
+				"Context":     "",
+				"Dockerfile":  "",
+				"ExportTags":  "false",
+				"JobType":     "postsubmit",
+				"Name":        "",
+				"PullBaseSHA": "",
+				"RepoName":    "",
+				"RepoOwner":   "",
+				"Platforms":   "linux/amd64,linux/arm64",
+				"Registries":  "europe-docker.pkg.dev/kyma-project/prod",
+			},
+			false,
+		),
 	)
 })
 
@@ -561,6 +797,7 @@ func Test_getTags(t *testing.T) {
 		sha            string
 		tagTemplate    tags.Tag
 		env            map[string]string
+		tagEnv         TagEnvConfig
 		additionalTags []tags.Tag
 		expectErr      bool
 		expectResult   []tags.Tag
@@ -617,6 +854,7 @@ func Test_getTags(t *testing.T) {
 			sha:         "da39a3ee5e6b4b0d3255bfef95601890afd80709",
 			tagTemplate: tags.Tag{Name: "TagTemplate", Value: `{{ .ShortSHA }}`},
 			env:         map[string]string{"CUSTOM_ENV": "customEnvValue"},
+			tagEnv:      TagEnvConfig{Allow: tags.EnvPatterns{"CUSTOM_*"}},
 			additionalTags: []tags.Tag{
 				{Name: "latest", Value: "latest"},
 				{Name: "Test", Value: "cookie"},
@@ -631,6 +869,24 @@ func Test_getTags(t *testing.T) {
 				{Name: "TagTemplate", Value: "da39a3ee"},
 			},
 		},
+		{
+			name:        "conditional tag is skipped",
+			sha:         "da39a3ee5e6b4b0d3255bfef95601890afd80709",
+			tagTemplate: tags.Tag{Name: "TagTemplate", Value: `{{ .ShortSHA }}`},
+			additionalTags: []tags.Tag{
+				{Name: "nightly", Value: "nightly", When: &tags.Condition{JobTypes: []string{"schedule"}}},
+			},
+			expectResult: []tags.Tag{{Name: "TagTemplate", Value: "da39a3ee"}},
+		},
+		{
+			name:           "env variable not in tag-env allowlist",
+			expectErr:      true,
+			sha:            "da39a3ee5e6b4b0d3255bfef95601890afd80709",
+			tagTemplate:    tags.Tag{Name: "TagTemplate", Value: `{{ .ShortSHA }}`},
+			env:            map[string]string{"AZURE_CLIENT_SECRET": "secret"},
+			tagEnv:         TagEnvConfig{Allow: tags.EnvPatterns{"CUSTOM_*"}},
+			additionalTags: []tags.Tag{{Name: "TestEnv", Value: `{{ .Env "AZURE_CLIENT_SECRET" }}`}},
+		},
 	}
 	for _, c := range tc {
 		t.Run(c.name, func(t *testing.T) {
@@ -642,7 +898,7 @@ func Test_getTags(t *testing.T) {
 			for k, v := range c.env {
 				t.Setenv(k, v)
 			}
-			got, err := getTags(logger, c.pr, c.sha, append(c.additionalTags, c.tagTemplate))
+			got, err := getTags(logger, c.pr, c.sha, append(c.additionalTags, c.tagTemplate), c.tagEnv.taggerOptions()...)
 			if err != nil && !c.expectErr {
 				t.Errorf("got error but didn't want to: %s", err)
 			}
@@ -807,6 +1063,43 @@ func Test_parseTags(t *testing.T) {
 			},
 			expectedTags: []tags.Tag{{Name: "AnotherTest", Value: "Another-" + commitGitState.BaseCommitSHA}, {Name: "Test", Value: "tag-value"}, expectedDefaultCommitTag(commitGitState.BaseCommitSHA)},
 		},
+		{
+			name: "parse default tag for git tag",
+			options: options{
+				gitState: tagGitState,
+				Config:   buildConfig,
+				tags: sets.Tags{
+					{Name: "Version", Value: `{{ .Version }}`},
+				},
+				logger: logger,
+			},
+			expectedTags: []tags.Tag{{Name: "Version", Value: "1.2.3"}, {Name: "default_tag", Value: "v1.2.3", Validation: defaultTagPushTag.Validation}},
+		},
+		{
+			name: "parse default tag for git tag which is not semantic version",
+			options: options{
+				gitState: GitStateConfig{BaseCommitSHA: "abcdef123456", RefType: RefTypeTag, TagName: "release-1"},
+				Config:   buildConfig,
+				logger:   logger,
+			},
+			expectErr: true,
+		},
+		{
+			name: "parse tag with git and image context",
+			options: options{
+				gitState: GitStateConfig{RepositoryName: "test-infra", BaseCommitSHA: "abcdef123456", BaseCommitRef: "refs/heads/feature/login"},
+				Config:   buildConfig,
+				name:     "tools/image-builder",
+				tags: sets.Tags{
+					{Name: "Context", Value: `{{ .RepoName }}-{{ .Branch }}-{{ .ImageName }}-{{ .Platforms }}`},
+				},
+				logger: logger,
+			},
+			expectedTags: []tags.Tag{
+				{Name: "Context", Value: "test-infra-feature-login-tools-image-builder-linux-amd64_linux-arm64"},
+				expectedDefaultCommitTag("abcdef123456"),
+			},
+		},
 		{
 			name: "parse bad tag template",
 			options: options{
@@ -852,7 +1145,7 @@ func Test_parseTags(t *testing.T) {
 	}
 }
 
-func Test_getDefaultTag(t *testing.T) {
+func Test_getPolicyTags(t *testing.T) {
 	g := NewGomegaWithT(t)
 
 	zapLogger, err := zap.NewProduction()
@@ -863,7 +1156,7 @@ func Test_getDefaultTag(t *testing.T) {
 	tests := []struct {
 		name    string
 		options options
-		want    tags.Tag
+		want    []tags.Tag
 		wantErr bool
 	}{
 		{
@@ -873,7 +1166,7 @@ func Test_getDefaultTag(t *testing.T) {
 				Config:   buildConfig,
 				logger:   logger,
 			},
-			want:    defaultPRTag,
+			want:    []tags.Tag{defaultPRTag},
 			wantErr: false,
 		},
 		{
@@ -883,16 +1176,97 @@ func Test_getDefaultTag(t *testing.T) {
 				Config:   buildConfig,
 				logger:   logger,
 			},
-			want:    defaultCommitTag,
+			want:    []tags.Tag{defaultCommitTag},
+			wantErr: false,
+		},
+		{
+			name: "Success - Git tag with built-in default tag",
+			options: options{
+				gitState: tagGitState,
+				Config:   buildConfig,
+				logger:   logger,
+			},
+			want:    []tags.Tag{defaultTagPushTag},
+			wantErr: false,
+		},
+		{
+			name: "Success - Git tag with configured default tag",
+			options: options{
+				gitState: tagGitState,
+				Config:   Config{DefaultTagPushTag: tags.Tag{Name: "default_tag", Value: `{{ .Version }}`, Validation: "^[0-9.]+$"}},
+				logger:   logger,
+			},
+			want:    []tags.Tag{{Name: "default_tag", Value: `{{ .Version }}`, Validation: "^[0-9.]+$"}},
 			wantErr: false,
 		},
+		{
+			name: "Success - Pull Request with additional tag",
+			options: options{
+				gitState: prGitState,
+				Config:   Config{DefaultPRTag: defaultPRTag, AdditionalPRTag: tags.Tag{Name: "pr", Value: "pr-{{ .PRNumber }}"}},
+				logger:   logger,
+			},
+			want:    []tags.Tag{{Name: "pr", Value: "pr-{{ .PRNumber }}"}, defaultPRTag},
+			wantErr: false,
+		},
+		{
+			name: "Success - Scheduled job uses default commit tag",
+			options: options{
+				gitState: GitStateConfig{JobType: "schedule", BaseCommitSHA: "abcdef123456"},
+				Config:   buildConfig,
+				logger:   logger,
+			},
+			want:    []tags.Tag{defaultCommitTag},
+			wantErr: false,
+		},
+		{
+			name: "Success - Tag policy overrides default tag fields",
+			options: options{
+				gitState: commitGitState,
+				Config: Config{
+					DefaultCommitTag: defaultCommitTag,
+					TagPolicies: TagPolicies{"postsubmit": {
+						{Tags: []tags.Tag{{Name: "latest", Value: "latest"}, defaultCommitTag}},
+					}},
+				},
+				logger: logger,
+			},
+			want:    []tags.Tag{{Name: "latest", Value: "latest"}, defaultCommitTag},
+			wantErr: false,
+		},
+		{
+			name: "Success - First tag policy matching the ref",
+			options: options{
+				gitState: GitStateConfig{BaseCommitSHA: "abcdef123456", BaseCommitRef: "refs/heads/release-1.2"},
+				Config: Config{TagPolicies: TagPolicies{"postsubmit": {
+					{Ref: "^refs/heads/main$", Tags: []tags.Tag{{Name: "latest", Value: "latest"}}},
+					{Ref: "^refs/heads/release-.*$", Tags: []tags.Tag{{Name: "release", Value: "release-{{ .ShortSHA }}"}}},
+					{Tags: []tags.Tag{defaultCommitTag}},
+				}}},
+				logger: logger,
+			},
+			want:    []tags.Tag{{Name: "release", Value: "release-{{ .ShortSHA }}"}},
+			wantErr: false,
+		},
+		{
+			name: "Failure - No tag policy matches the ref",
+			options: options{
+				gitState: GitStateConfig{BaseCommitSHA: "abcdef123456", BaseCommitRef: "refs/heads/feature"},
+				Config: Config{TagPolicies: TagPolicies{"postsubmit": {
+					{Ref: "^refs/heads/main$", Tags: []tags.Tag{{Name: "latest", Value: "latest"}}},
+				}}},
+				logger: logger,
+			},
+			want:    nil,
+			wantErr: true,
+		},
 		{
 			name: "Failure - No PR number or commit SHA",
 			options: options{
 				gitState: GitStateConfig{},
 				logger:   logger,
 			},
-			want:    tags.Tag{},
+			want:    nil,
 			wantErr: true,
 		},
 	}
@@ -900,7 +1274,7 @@ func Test_getDefaultTag(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			logger := tt.options.logger
-			got, err := getDefaultTag(logger, tt.options)
+			got, err := getPolicyTags(logger, tt.options)
 			if tt.wantErr {
 				g.Expect(err).To(HaveOccurred())
 			} else {
@@ -911,6 +1285,120 @@ func Test_getDefaultTag(t *testing.T) {
 	}
 }
 
+func Test_parseTags_github_event_branch(t *testing.T) {
+	tc := []struct {
+		name      string
+		eventName string
+		eventPath string
+	}{
+		{name: "branch push", eventName: "push", eventPath: "./test_fixture/push_event.json"},
+		{name: "pull request uses its base branch", eventName: "pull_request", eventPath: "./test_fixture/pull_request_opened.json"},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			t.Setenv("GITHUB_EVENT_NAME", c.eventName)
+			t.Setenv("GITHUB_EVENT_PATH", c.eventPath)
+			gitState, err := loadGithubActionsGitState()
+			if err != nil {
+				t.Fatalf("failed to load git state: %s", err)
+			}
+			o := options{
+				gitState: gitState,
+				Config: Config{TagPolicies: TagPolicies{
+					"presubmit":  {{Tags: []tags.Tag{{Name: "branch", Value: "{{ .Branch }}-{{ .BaseRef }}"}}}},
+					"postsubmit": {{Tags: []tags.Tag{{Name: "branch", Value: "{{ .Branch }}-{{ .BaseRef }}"}}}},
+				}},
+				logger: zap.NewNop().Sugar(),
+			}
+
+			got, err := parseTags(o.logger, o)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err)
+			}
+			expected := []tags.Tag{{Name: "branch", Value: "main-refs-heads-main"}}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("parseTags(): got %v, want %v", got, expected)
+			}
+		})
+	}
+}
+
+func Test_parseTags_github_push_ref_condition(t *testing.T) {
+	t.Setenv("GITHUB_EVENT_NAME", "push")
+	t.Setenv("GITHUB_EVENT_PATH", "./test_fixture/push_event.json")
+	gitState, err := loadGithubActionsGitState()
+	if err != nil {
+		t.Fatalf("failed to load git state: %s", err)
+	}
+	o := options{
+		gitState: gitState,
+		Config: Config{TagPolicies: TagPolicies{"postsubmit": {{Tags: []tags.Tag{
+			{Name: "latest", Value: "latest", When: &tags.Condition{JobTypes: []string{"postsubmit"}, Ref: "^refs/heads/main$"}},
+			{Name: "release", Value: "{{ .GitTag }}", When: &tags.Condition{Ref: "^refs/tags/"}},
+			{Name: "commit", Value: "{{ .ShortSHA }}"},
+		}}}}},
+		logger: zap.NewNop().Sugar(),
+	}
+
+	got, err := parseTags(o.logger, o)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err)
+	}
+	expected := []tags.Tag{{Name: "latest", Value: "latest"}, {Name: "commit", Value: "d42f5051"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("parseTags(): got %v, want %v", got, expected)
+	}
+}
+
+func Test_getPolicyTags_github_events(t *testing.T) {
+	policies := TagPolicies{
+		"presubmit": {
+			{Ref: "^refs/heads/main$", Tags: []tags.Tag{{Name: "main_pr", Value: "PR-{{ .PRNumber }}"}}},
+		},
+		"postsubmit": {
+			{Ref: "^refs/heads/main$", Tags: []tags.Tag{{Name: "latest", Value: "latest"}}},
+		},
+	}
+	tc := []struct {
+		name      string
+		eventName string
+		eventPath string
+		expected  []tags.Tag
+	}{
+		{
+			name:      "branch push matches the ref scoped policy",
+			eventName: "push",
+			eventPath: "./test_fixture/push_event.json",
+			expected:  []tags.Tag{{Name: "latest", Value: "latest"}},
+		},
+		{
+			name:      "pull request matches the ref scoped policy of its base branch",
+			eventName: "pull_request",
+			eventPath: "./test_fixture/pull_request_opened.json",
+			expected:  []tags.Tag{{Name: "main_pr", Value: "PR-{{ .PRNumber }}"}},
+		},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			t.Setenv("GITHUB_EVENT_NAME", c.eventName)
+			t.Setenv("GITHUB_EVENT_PATH", c.eventPath)
+			gitState, err := loadGithubActionsGitState()
+			if err != nil {
+				t.Fatalf("failed to load git state: %s", err)
+			}
+			o := options{gitState: gitState, Config: Config{TagPolicies: policies}, logger: zap.NewNop().Sugar()}
+
+			got, err := getPolicyTags(o.logger, o)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(got, c.expected) {
+				t.Errorf("getPolicyTags(): got %v, want %v", got, c.expected)
+			}
+		})
+	}
+}
+
 type mockSignerFactory struct{}
 
 func (m *mockSignerFactory) NewSigner() (sign.Signer, error) {
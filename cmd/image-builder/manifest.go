@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/kyma-project/test-infra/pkg/github/actions"
+	"github.com/kyma-project/test-infra/pkg/imagebuilder"
+	"github.com/kyma-project/test-infra/pkg/tags"
+	"gopkg.in/yaml.v3"
+	errutil "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// BuildManifest holds definitions of multiple images built in a single image-builder run.
+type BuildManifest struct {
+	// Images is a list of images to build
+	Images []ManifestImage `yaml:"images" json:"images"`
+}
+
+// ManifestImage defines a single image in the build manifest.
+// Empty fields are inherited from the command line flags.
+type ManifestImage struct {
+	// Name of the image to be built
+	Name string `yaml:"name" json:"name"`
+	// Context is a path to the build context directory
+	Context string `yaml:"context,omitempty" json:"context,omitempty"`
+	// Dockerfile is a path to the dockerfile relative to the context
+	Dockerfile string `yaml:"dockerfile,omitempty" json:"dockerfile,omitempty"`
+	// Target is the build stage in the dockerfile to use as the target
+	Target string `yaml:"target,omitempty" json:"target,omitempty"`
+	// BuildArgs are additional arguments passed to the image build, added to the ones provided by flags
+	BuildArgs map[string]string `yaml:"build-args,omitempty" json:"build-args,omitempty"`
+	// Platforms the image is built for, it replaces platforms provided by flags
+	Platforms []string `yaml:"platforms,omitempty" json:"platforms,omitempty"`
+	// Tags are additional tags for the image in the same format as the --tag flag value, added to the ones provided by flags
+	Tags []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+}
+
+// LoadBuildManifest reads and validates the build manifest from the file.
+func LoadBuildManifest(path string) (BuildManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return BuildManifest{}, fmt.Errorf("failed reading build manifest file: %w", err)
+	}
+
+	var manifest BuildManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return BuildManifest{}, fmt.Errorf("failed parsing build manifest file: %w", err)
+	}
+
+	if err := manifest.Validate(); err != nil {
+		return BuildManifest{}, fmt.Errorf("invalid build manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// Validate checks if the manifest contains at least one image and that image names are set and unique.
+func (m BuildManifest) Validate() error {
+	if len(m.Images) == 0 {
+		return fmt.Errorf("no images defined")
+	}
+
+	var errs []error
+	names := make(map[string]bool)
+	for i, img := range m.Images {
+		if img.Name == "" {
+			errs = append(errs, fmt.Errorf("image at index %d has no name", i))
+			continue
+		}
+		if names[img.Name] {
+			errs = append(errs, fmt.Errorf("image %s is defined more than once", img.Name))
+		}
+		names[img.Name] = true
+	}
+	return errutil.NewAggregate(errs)
+}
+
+// imageOptions returns options for building the manifest image.
+// Values defined for the image take precedence over values provided by flags.
+func imageOptions(o options, img ManifestImage) (options, error) {
+	o.name = img.Name
+	if img.Context != "" {
+		o.context = img.Context
+	}
+	if img.Dockerfile != "" {
+		o.dockerfile = img.Dockerfile
+	}
+	if img.Target != "" {
+		o.target = img.Target
+	}
+	if len(img.Platforms) > 0 {
+		o.platforms = slices.Clone(img.Platforms)
+	}
+
+	// Options are shared between builds running in parallel, copy slices before appending.
+	o.buildArgs = slices.Clone(o.buildArgs)
+	argNames := make([]string, 0, len(img.BuildArgs))
+	for name := range img.BuildArgs {
+		argNames = append(argNames, name)
+	}
+	sort.Strings(argNames)
+	for _, name := range argNames {
+		o.buildArgs = append(o.buildArgs, tags.Tag{Name: name, Value: img.BuildArgs[name]})
+	}
+
+	o.tags = slices.Clone(o.tags)
+	for _, t := range img.Tags {
+		if err := o.tags.Set(t); err != nil {
+			return options{}, fmt.Errorf("failed parsing tag %s of image %s: %w", t, img.Name, err)
+		}
+	}
+
+	return o, nil
+}
+
+// runManifestBuild builds all images from the manifest with the given backend.
+// Builds run in parallel, at most o.buildConcurrency at the same time.
+// A failed build doesn't stop other builds, results of all builds are aggregated into one combined report.
+// It returns an error if any of the builds failed.
+func runManifestBuild(ctx context.Context, o options, backend BuildBackend, manifest BuildManifest) error {
+	concurrency := o.buildConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	fmt.Printf("Building %d images from build manifest, max %d builds in parallel.\n", len(manifest.Images), concurrency)
+
+	results := make([]imagebuilder.ImageBuildResult, len(manifest.Images))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, img := range manifest.Images {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = buildManifestImage(ctx, o, backend, img)
+		}()
+	}
+	wg.Wait()
+
+	combined := imagebuilder.NewCombinedBuildReport(results)
+	o.logger.Debugw("Combined build report created", "buildReport", combined)
+
+	var errs []error
+	for _, r := range combined.Builds {
+		fmt.Printf("Image %s build finished with status: %s\n", r.Name, r.Status)
+		if r.Status != "Succeeded" {
+			errs = append(errs, fmt.Errorf("image %s build failed: %s", r.Name, r.Error))
+		}
+	}
+
+	if o.ciSystem == GithubActions {
+		if err := setGithubManifestOutputs(o, combined); err != nil {
+			return err
+		}
+	}
+
+	if o.buildReportPath != "" {
+		if err := imagebuilder.WriteCombinedReportToFile(combined, o.buildReportPath); err != nil {
+			return fmt.Errorf("failed writing build report to file: %w", err)
+		}
+	}
+
+	return errutil.NewAggregate(errs)
+}
+
+// buildManifestImage builds a single image from the manifest and converts the outcome to the image build result.
+func buildManifestImage(ctx context.Context, o options, backend BuildBackend, img ManifestImage) imagebuilder.ImageBuildResult {
+	result := imagebuilder.ImageBuildResult{Name: img.Name, Status: "Failed"}
+
+	imgOptions, err := imageOptions(o, img)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	imgOptions.logger = o.logger.With("image", img.Name)
+	output := newLinePrefixWriter(o.buildOutput(), fmt.Sprintf("[%s] ", img.Name))
+	defer output.Flush()
+	imgOptions.output = output
+
+	buildResult, err := backend.Build(ctx, imgOptions)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Report = buildResult.Report
+	if buildResult.Failed() {
+		result.Error = fmt.Sprintf("build finished with status: %s", buildResult.Status)
+		return result
+	}
+	result.Status = "Succeeded"
+	return result
+}
+
+// linePrefixWriter prefixes each line written to out with the prefix.
+// Incomplete lines are buffered until they are finished or the writer is flushed,
+// so lines of writers sharing out aren't mixed.
+type linePrefixWriter struct {
+	mu     sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    []byte
+}
+
+// newLinePrefixWriter creates the linePrefixWriter writing lines prefixed with the prefix to out.
+func newLinePrefixWriter(out io.Writer, prefix string) *linePrefixWriter {
+	return &linePrefixWriter{out: out, prefix: prefix}
+}
+
+// Write writes finished lines from p prefixed with the prefix and buffers the incomplete last line.
+func (w *linePrefixWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := w.writeLine(w.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes the buffered incomplete line, if any, finished with a newline.
+func (w *linePrefixWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.writeLine(append(w.buf, '\n'))
+	w.buf = nil
+	return err
+}
+
+// writeLine writes the prefixed line to out with a single write call.
+func (w *linePrefixWriter) writeLine(line []byte) error {
+	_, err := w.out.Write(append([]byte(w.prefix), line...))
+	return err
+}
+
+// setGithubManifestOutputs sets GitHub Actions outputs with data from the combined build report.
+func setGithubManifestOutputs(o options, report *imagebuilder.CombinedBuildReport) error {
+	fmt.Println("Setting GitHub outputs.")
+
+	imagesJSON, err := json.Marshal(report.Images())
+	if err != nil {
+		return fmt.Errorf("cannot marshal list of images: %w", err)
+	}
+
+	digestsJSON, err := json.Marshal(report.Digests())
+	if err != nil {
+		return fmt.Errorf("cannot marshal image digests: %w", err)
+	}
+
+	buildReportJSON, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("cannot marshal build report: %w", err)
+	}
+
+	status := BuildStatusSucceeded
+	if report.Status != "Succeeded" {
+		status = BuildStatusFailed
+	}
+
+	o.logger.Debugw("Set GitHub outputs", "images", string(imagesJSON), "digests", string(digestsJSON), "adoResult", status)
+
+	if err := actions.SetOutput("images", string(imagesJSON)); err != nil {
+		return fmt.Errorf("cannot set images GitHub output: %w", err)
+	}
+
+	if err := actions.SetOutput("digests", string(digestsJSON)); err != nil {
+		return fmt.Errorf("cannot set digests GitHub output: %w", err)
+	}
+
+	if err := actions.SetOutput("build-report", string(buildReportJSON)); err != nil {
+		return fmt.Errorf("cannot set build-report GitHub output: %w", err)
+	}
+
+	if err := actions.SetOutput("adoResult", status); err != nil {
+		return fmt.Errorf("cannot set adoResult GitHub output: %w", err)
+	}
+	return nil
+}
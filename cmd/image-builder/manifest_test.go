@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kyma-project/test-infra/pkg/imagebuilder"
+	"github.com/kyma-project/test-infra/pkg/sets"
+	"github.com/kyma-project/test-infra/pkg/tags"
+	"go.uber.org/zap"
+)
+
+func TestLoadBuildManifest(t *testing.T) {
+	tc := []struct {
+		name      string
+		manifest  string
+		expected  BuildManifest
+		expectErr bool
+	}{
+		{
+			name: "full manifest, pass",
+			manifest: `images:
+- name: image-a
+  context: a
+  dockerfile: a/Dockerfile
+  target: release
+  build-args:
+    BIN: a
+  platforms:
+  - linux/amd64
+  tags:
+  - latest
+- name: image-b`,
+			expected: BuildManifest{Images: []ManifestImage{
+				{
+					Name:       "image-a",
+					Context:    "a",
+					Dockerfile: "a/Dockerfile",
+					Target:     "release",
+					BuildArgs:  map[string]string{"BIN": "a"},
+					Platforms:  []string{"linux/amd64"},
+					Tags:       []string{"latest"},
+				},
+				{Name: "image-b"},
+			}},
+		},
+		{
+			name:      "no images, fail",
+			manifest:  `images: []`,
+			expectErr: true,
+		},
+		{
+			name: "duplicated image name, fail",
+			manifest: `images:
+- name: image-a
+- name: image-a`,
+			expectErr: true,
+		},
+		{
+			name: "missing image name, fail",
+			manifest: `images:
+- context: a`,
+			expectErr: true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "manifest.yaml")
+			if err := os.WriteFile(path, []byte(c.manifest), 0644); err != nil {
+				t.Fatalf("failed writing manifest file: %s", err)
+			}
+
+			got, err := LoadBuildManifest(path)
+			if err != nil && !c.expectErr {
+				t.Errorf("got unexpected error: %s", err)
+			}
+			if err == nil && c.expectErr {
+				t.Error("error expected, but no one occured")
+			}
+			if !reflect.DeepEqual(got, c.expected) {
+				t.Errorf("LoadBuildManifest(): got %+v, want %+v", got, c.expected)
+			}
+		})
+	}
+}
+
+func Test_imageOptions(t *testing.T) {
+	o := options{
+		context:    ".",
+		dockerfile: "Dockerfile",
+		buildArgs:  sets.Tags{{Name: "COMMON", Value: "1"}},
+		tags:       sets.Tags{{Name: "latest", Value: "latest"}},
+		platforms:  sets.Strings{"linux/amd64", "linux/arm64"},
+	}
+	img := ManifestImage{
+		Name:       "image-a",
+		Context:    "a",
+		Dockerfile: "a/Dockerfile",
+		BuildArgs:  map[string]string{"BIN": "a", "ARCH": "amd64"},
+		Platforms:  []string{"linux/amd64"},
+		Tags:       []string{"stable=v1"},
+	}
+
+	got, err := imageOptions(o, img)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err)
+	}
+
+	expected := options{
+		name:       "image-a",
+		context:    "a",
+		dockerfile: "a/Dockerfile",
+		buildArgs:  sets.Tags{{Name: "COMMON", Value: "1"}, {Name: "ARCH", Value: "amd64"}, {Name: "BIN", Value: "a"}},
+		tags:       sets.Tags{{Name: "latest", Value: "latest"}, {Name: "stable", Value: "v1"}},
+		platforms:  sets.Strings{"linux/amd64"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("imageOptions(): got %+v, want %+v", got, expected)
+	}
+	if len(o.tags) != 1 || len(o.buildArgs) != 1 {
+		t.Errorf("imageOptions() modified source options: %+v", o)
+	}
+}
+
+// fakeBackend builds images by returning predefined results and tracks parallel builds.
+type fakeBackend struct {
+	mu          sync.Mutex
+	running     int
+	maxRunning  int
+	results     map[string]*BuildResult
+	failedNames map[string]bool
+	// logs are written to the build output of the image with the name
+	logs map[string]string
+}
+
+func (b *fakeBackend) Build(_ context.Context, o options) (*BuildResult, error) {
+	b.mu.Lock()
+	b.running++
+	if b.running > b.maxRunning {
+		b.maxRunning = b.running
+	}
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		b.running--
+		b.mu.Unlock()
+	}()
+
+	if logs, ok := b.logs[o.name]; ok {
+		fmt.Fprint(o.buildOutput(), logs)
+	}
+	if b.failedNames[o.name] {
+		return nil, fmt.Errorf("failed triggering pipeline")
+	}
+	return b.results[o.name], nil
+}
+
+func Test_runManifestBuild(t *testing.T) {
+	reportA := &imagebuilder.BuildReport{Status: "Succeeded", Name: "image-a", Images: []string{"reg/image-a:v1"}, Digest: "sha256:aaa"}
+	reportC := &imagebuilder.BuildReport{Status: "Failed", Name: "image-c"}
+	backend := &fakeBackend{
+		results: map[string]*BuildResult{
+			"image-a": {Status: BuildStatusSucceeded, Report: reportA},
+			"image-c": {Status: BuildStatusFailed, Report: reportC},
+		},
+		failedNames: map[string]bool{"image-b": true},
+	}
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+	o := options{
+		logger:           zap.NewNop().Sugar(),
+		buildConcurrency: 2,
+		buildReportPath:  reportPath,
+		tags:             sets.Tags{tags.Tag{Name: "latest", Value: "latest"}},
+	}
+	manifest := BuildManifest{Images: []ManifestImage{{Name: "image-a"}, {Name: "image-b"}, {Name: "image-c"}}}
+
+	err := runManifestBuild(context.Background(), o, backend, manifest)
+	if err == nil {
+		t.Error("error expected, but no one occured")
+	}
+	if backend.maxRunning > 2 {
+		t.Errorf("expected at most 2 builds running in parallel, got %d", backend.maxRunning)
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("failed reading combined report: %s", err)
+	}
+	expected := `{"status":"Failed","builds":[` +
+		`{"image_name":"image-a","status":"Succeeded","report":{"status":"Succeeded","pushed":false,"signed":false,"image_name":"image-a","images_list":["reg/image-a:v1"],"digest":"sha256:aaa","tags":null,"repository_path":"","architectures":null}},` +
+		`{"image_name":"image-b","status":"Failed","error":"failed triggering pipeline"},` +
+		`{"image_name":"image-c","status":"Failed","report":{"status":"Failed","pushed":false,"signed":false,"image_name":"image-c","images_list":null,"digest":"","tags":null,"repository_path":"","architectures":null},"error":"build finished with status: failed"}]}`
+	if string(data) != expected {
+		t.Errorf("combined report mismatch:\ngot  %s\nwant %s", data, expected)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes from parallel builds.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func Test_runManifestBuild_output(t *testing.T) {
+	backend := &fakeBackend{
+		results: map[string]*BuildResult{
+			"image-a": {Status: BuildStatusSucceeded, Report: &imagebuilder.BuildReport{Status: "Succeeded", Name: "image-a"}},
+			"image-b": {Status: BuildStatusSucceeded, Report: &imagebuilder.BuildReport{Status: "Succeeded", Name: "image-b"}},
+		},
+		logs: map[string]string{
+			"image-a": "[Build] step a1\n[Build] step a2\n",
+			"image-b": "[Build] step b1\nlast line without newline",
+		},
+	}
+	output := &syncBuffer{}
+	o := options{
+		logger:           zap.NewNop().Sugar(),
+		buildConcurrency: 2,
+		output:           output,
+	}
+	manifest := BuildManifest{Images: []ManifestImage{{Name: "image-a"}, {Name: "image-b"}}}
+
+	if err := runManifestBuild(context.Background(), o, backend, manifest); err != nil {
+		t.Fatalf("got unexpected error: %s", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(output.buf.String(), "\n"), "\n")
+	sort.Strings(lines)
+	expected := []string{
+		"[image-a] [Build] step a1",
+		"[image-a] [Build] step a2",
+		"[image-b] [Build] step b1",
+		"[image-b] last line without newline",
+	}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("build output mismatch:\ngot  %q\nwant %q", lines, expected)
+	}
+}
+
+func Test_linePrefixWriter(t *testing.T) {
+	tc := []struct {
+		name     string
+		writes   []string
+		expected string
+	}{
+		{
+			name:     "whole lines",
+			writes:   []string{"line 1\nline 2\n"},
+			expected: "[image] line 1\n[image] line 2\n",
+		},
+		{
+			name:     "line split across writes",
+			writes:   []string{"li", "ne 1\nline", " 2\n"},
+			expected: "[image] line 1\n[image] line 2\n",
+		},
+		{
+			name:     "incomplete last line is flushed",
+			writes:   []string{"line 1\nline 2"},
+			expected: "[image] line 1\n[image] line 2\n",
+		},
+		{
+			name:     "empty line",
+			writes:   []string{"\n"},
+			expected: "[image] \n",
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			var out bytes.Buffer
+			w := newLinePrefixWriter(&out, "[image] ")
+			for _, s := range c.writes {
+				if _, err := w.Write([]byte(s)); err != nil {
+					t.Fatalf("got unexpected error: %s", err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatalf("got unexpected error: %s", err)
+			}
+			if out.String() != c.expected {
+				t.Errorf("output mismatch:\ngot  %q\nwant %q", out.String(), c.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kyma-project/test-infra/pkg/azuredevops/pipelines"
+	"github.com/kyma-project/test-infra/pkg/github/actions"
+	"github.com/kyma-project/test-infra/pkg/tags"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats of tags generated in the parse-tags-only mode.
+const (
+	// TagsFormatJSON writes the JSON array of tags to the tags output file.
+	TagsFormatJSON = "json"
+	// TagsFormatYAML writes the YAML list of tags to the tags output file.
+	TagsFormatYAML = "yaml"
+	// TagsFormatDotenv writes TAG_<name>=<value> lines to the tags output file.
+	TagsFormatDotenv = "dotenv"
+	// TagsFormatGitHub sets GitHub Actions step outputs.
+	TagsFormatGitHub = "github"
+	// TagsFormatADO sets Azure DevOps output variables.
+	TagsFormatADO = "ado"
+	// TagsFormatRefs writes fully qualified image references, one per line, to the tags output file.
+	TagsFormatRefs = "refs"
+)
+
+// supportedTagsFormats are values allowed in the --tags-output-format flag.
+var supportedTagsFormats = []string{TagsFormatJSON, TagsFormatYAML, TagsFormatDotenv, TagsFormatGitHub, TagsFormatADO, TagsFormatRefs}
+
+// tagsOutputKey is the output variable with all tags as a JSON array, set by the github and ado formats.
+const tagsOutputKey = "tags"
+
+// outputKeyInvalidChars matches characters not allowed in names of output variables.
+var outputKeyInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// outputSetter sets the output variable of the CI system.
+type outputSetter func(key, value string) error
+
+// setADOOutput sets the ADO output variable.
+func setADOOutput(key, value string) error {
+	pipelines.SetVariable(key, value, false, true)
+	return nil
+}
+
+// validateTagsOutputFormat returns an error if the format isn't supported
+// or the options required by the format are missing.
+func validateTagsOutputFormat(o options) error {
+	if !slices.Contains(supportedTagsFormats, o.tagsOutputFormat) {
+		return fmt.Errorf("unsupported tags output format %s, supported formats: %v", o.tagsOutputFormat, supportedTagsFormats)
+	}
+	if o.tagsOutputFormat == TagsFormatRefs && o.name == "" {
+		return fmt.Errorf("flag '--name' is required by the %s tags output format", TagsFormatRefs)
+	}
+	return nil
+}
+
+// writeTagsOutput writes parsed tags in the format set with the --tags-output-format flag.
+// The github and ado formats set output variables of the CI system, other formats are written to the tags output file.
+func writeTagsOutput(logger Logger, o options, parsedTags []tags.Tag) error {
+	switch o.tagsOutputFormat {
+	case TagsFormatGitHub:
+		return setTagsOutputs(actions.SetOutput, parsedTags)
+	case TagsFormatADO:
+		return setTagsOutputs(setADOOutput, parsedTags)
+	}
+	if o.tagsOutputFile == "" {
+		logger.Debugw("tags output file not provided, skipping writing tags")
+		return nil
+	}
+	data, err := formatTags(o.tagsOutputFormat, parsedTags, outputRegistries(o), o.name)
+	if err != nil {
+		return err
+	}
+	logger.Debugw("successfully formatted image tags", "format", o.tagsOutputFormat, "tags", tagNames(parsedTags))
+	if err := writeOutputFile(logger, o.tagsOutputFile, data); err != nil {
+		return fmt.Errorf("failed to write tags to file: %w", err)
+	}
+	logger.Infow("tags successfully written to file", "tagsOutputFile", o.tagsOutputFile, "format", o.tagsOutputFormat, "tags", tagNames(parsedTags))
+	return nil
+}
+
+// formatTags returns parsed tags in the file format.
+func formatTags(format string, parsedTags []tags.Tag, registries []string, name string) ([]byte, error) {
+	switch format {
+	case TagsFormatJSON:
+		return tagsAsJSON(parsedTags)
+	case TagsFormatYAML:
+		data, err := yaml.Marshal(parsedTags)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal tags to yaml, got error: %w", err)
+		}
+		return data, nil
+	case TagsFormatDotenv:
+		var buf bytes.Buffer
+		for _, tag := range parsedTags {
+			fmt.Fprintf(&buf, "%s=%s\n", tagOutputKey(tag), tag.Value)
+		}
+		return buf.Bytes(), nil
+	case TagsFormatRefs:
+		var buf bytes.Buffer
+		for _, image := range imageReferences(registries, name, parsedTags) {
+			fmt.Fprintln(&buf, image)
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported tags output format %s", format)
+	}
+}
+
+// setTagsOutputs sets the TAG_<name> output variable for each tag
+// and the tags output variable with all tags as a JSON array.
+func setTagsOutputs(set outputSetter, parsedTags []tags.Tag) error {
+	for _, tag := range parsedTags {
+		if err := set(tagOutputKey(tag), tag.Value); err != nil {
+			return fmt.Errorf("failed to set output of tag %s: %w", tag.Name, err)
+		}
+	}
+	jsonTags, err := json.Marshal(parsedTags)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tags to json, got error: %w", err)
+	}
+	if err := set(tagsOutputKey, string(jsonTags)); err != nil {
+		return fmt.Errorf("failed to set %s output: %w", tagsOutputKey, err)
+	}
+	return nil
+}
+
+// tagOutputKey returns the name of the output variable of the tag, the same as used by the --export-tags flag.
+// Characters not allowed in variable names are replaced with "_".
+func tagOutputKey(tag tags.Tag) string {
+	return "TAG_" + outputKeyInvalidChars.ReplaceAllString(tag.Name, "_")
+}
+
+// outputRegistries returns all configured registries, the registry first and then the dev registry, without duplicates.
+func outputRegistries(o options) []string {
+	var registries []string
+	for _, registry := range append(slices.Clone(o.Registry), o.DevRegistry...) {
+		registry = strings.TrimSuffix(registry, "/")
+		if !slices.Contains(registries, registry) {
+			registries = append(registries, registry)
+		}
+	}
+	return registries
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kyma-project/test-infra/pkg/tags"
+	"go.uber.org/zap"
+)
+
+var outputTestTags = []tags.Tag{
+	{Name: "default_tag", Value: "v20240101-abc1234"},
+	{Name: "release-tag", Value: "1.2.3"},
+}
+
+func Test_formatTags(t *testing.T) {
+	tc := []struct {
+		name       string
+		format     string
+		registries []string
+		expected   string
+		expectErr  bool
+	}{
+		{
+			name:     "json",
+			format:   TagsFormatJSON,
+			expected: `[{"name":"default_tag","value":"v20240101-abc1234"},{"name":"release-tag","value":"1.2.3"}]`,
+		},
+		{
+			name:   "yaml",
+			format: TagsFormatYAML,
+			expected: `- name: default_tag
+  value: v20240101-abc1234
+  validation: ""
+- name: release-tag
+  value: 1.2.3
+  validation: ""
+`,
+		},
+		{
+			name:   "dotenv",
+			format: TagsFormatDotenv,
+			expected: `TAG_default_tag=v20240101-abc1234
+TAG_release_tag=1.2.3
+`,
+		},
+		{
+			name:       "fully qualified references across registries",
+			format:     TagsFormatRefs,
+			registries: []string{"europe-docker.pkg.dev/kyma-project/prod", "europe-docker.pkg.dev/kyma-project/dev"},
+			expected: `europe-docker.pkg.dev/kyma-project/prod/image:v20240101-abc1234
+europe-docker.pkg.dev/kyma-project/prod/image:1.2.3
+europe-docker.pkg.dev/kyma-project/dev/image:v20240101-abc1234
+europe-docker.pkg.dev/kyma-project/dev/image:1.2.3
+`,
+		},
+		{
+			name:      "unsupported format",
+			format:    "xml",
+			expectErr: true,
+		},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := formatTags(c.format, outputTestTags, c.registries, "image")
+			if err != nil && !c.expectErr {
+				t.Errorf("got error but didn't want to: %v", err)
+			}
+			if err == nil && c.expectErr {
+				t.Errorf("didn't get error but wanted to")
+			}
+			if string(got) != c.expected {
+				t.Errorf("formatTags() = %q, expected %q", got, c.expected)
+			}
+		})
+	}
+}
+
+func Test_setTagsOutputs(t *testing.T) {
+	outputs := map[string]string{}
+	set := func(key, value string) error {
+		outputs[key] = value
+		return nil
+	}
+	if err := setTagsOutputs(set, outputTestTags); err != nil {
+		t.Fatalf("got error but didn't want to: %v", err)
+	}
+	expected := map[string]string{
+		"TAG_default_tag": "v20240101-abc1234",
+		"TAG_release_tag": "1.2.3",
+		"tags":            `[{"name":"default_tag","value":"v20240101-abc1234"},{"name":"release-tag","value":"1.2.3"}]`,
+	}
+	if !reflect.DeepEqual(outputs, expected) {
+		t.Errorf("outputs = %v, expected %v", outputs, expected)
+	}
+}
+
+func Test_writeTagsOutput_github(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "github_output")
+	if err := os.WriteFile(outputFile, nil, 0644); err != nil {
+		t.Fatalf("failed creating output file: %v", err)
+	}
+	t.Setenv("GITHUB_OUTPUT", outputFile)
+
+	o := options{tagsOutputFormat: TagsFormatGitHub}
+	if err := writeTagsOutput(zap.NewNop().Sugar(), o, outputTestTags[:1]); err != nil {
+		t.Fatalf("got error but didn't want to: %v", err)
+	}
+	got, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed reading output file: %v", err)
+	}
+	expected := "TAG_default_tag=v20240101-abc1234\ntags=[{\"name\":\"default_tag\",\"value\":\"v20240101-abc1234\"}]\n"
+	if string(got) != expected {
+		t.Errorf("GitHub outputs = %q, expected %q", got, expected)
+	}
+}
+
+func Test_validateTagsOutputFormat(t *testing.T) {
+	tc := []struct {
+		name      string
+		o         options
+		expectErr bool
+	}{
+		{name: "supported format", o: options{tagsOutputFormat: TagsFormatDotenv}},
+		{name: "unsupported format", o: options{tagsOutputFormat: "xml"}, expectErr: true},
+		{name: "refs format with image name", o: options{tagsOutputFormat: TagsFormatRefs, name: "image"}},
+		{name: "refs format without image name", o: options{tagsOutputFormat: TagsFormatRefs}, expectErr: true},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateTagsOutputFormat(c.o)
+			if err != nil && !c.expectErr {
+				t.Errorf("got error but didn't want to: %v", err)
+			}
+			if err == nil && c.expectErr {
+				t.Errorf("didn't get error but wanted to")
+			}
+		})
+	}
+}
+
+func Test_outputRegistries(t *testing.T) {
+	o := options{Config: Config{
+		Registry:    Registry{"europe-docker.pkg.dev/kyma-project/prod"},
+		DevRegistry: Registry{"europe-docker.pkg.dev/kyma-project/dev", "europe-docker.pkg.dev/kyma-project/prod/"},
+	}}
+	expected := []string{"europe-docker.pkg.dev/kyma-project/prod", "europe-docker.pkg.dev/kyma-project/dev"}
+	if got := outputRegistries(o); !reflect.DeepEqual(got, expected) {
+		t.Errorf("outputRegistries() = %v, expected %v", got, expected)
+	}
+}
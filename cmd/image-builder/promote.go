@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/kyma-project/test-infra/pkg/imagebuilder"
+	"github.com/kyma-project/test-infra/pkg/tags"
+)
+
+// PromoteCommand copies the image built before, e.g. in the dev registry, by digest to the registry from the config.
+const PromoteCommand = "promote"
+
+// imageSigner signs images with signers enabled for the repository, see signImages.
+type imageSigner func(o *options, images []string) error
+
+// promoteBackend promotes images instead of building them.
+// The image is copied by digest, so the promoted image has exactly the same bytes as the validated one.
+// A multi-arch image is copied together with all images from its index.
+type promoteBackend struct {
+	// remoteOptions are passed to all registry calls, e.g. to use the registry transport in tests
+	remoteOptions []remote.Option
+	sign          imageSigner
+}
+
+func newPromoteBackend() *promoteBackend {
+	return &promoteBackend{
+		remoteOptions: []remote.Option{remote.WithAuthFromKeychain(authn.DefaultKeychain)},
+		sign:          signImages,
+	}
+}
+
+// Build copies the image provided with the --source flag to the registry from the config and tags it with parsed tags.
+// If the --sign flag is set, the promoted images are signed with signers enabled for the repository.
+// If copying or signing fails after the source image was read, the result has the failed status and the error is not returned.
+func (b *promoteBackend) Build(ctx context.Context, o options) (*BuildResult, error) {
+	fmt.Printf("Promoting image %s.\n", o.promoteSource)
+	logger := o.logger.With("command", PromoteCommand)
+
+	if len(o.Registry) == 0 {
+		return nil, fmt.Errorf("promote failed, no registry configured")
+	}
+
+	parsedTags, err := promoteTags(logger, o)
+	if err != nil {
+		return nil, fmt.Errorf("promote failed, failed parsing tags: %w", err)
+	}
+
+	source, err := name.ParseReference(o.promoteSource)
+	if err != nil {
+		return nil, fmt.Errorf("promote failed, invalid source image %s: %w", o.promoteSource, err)
+	}
+	opts := append([]remote.Option{remote.WithContext(ctx)}, b.remoteOptions...)
+	desc, err := remote.Get(source, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("promote failed, failed reading source image %s: %w", source, err)
+	}
+	architectures, err := descriptorPlatforms(desc)
+	if err != nil {
+		return nil, fmt.Errorf("promote failed, failed reading platforms of source image %s: %w", source, err)
+	}
+	logger.Debugw("Source image resolved", "source", source.String(), "digest", desc.Digest.String(), "mediaType", desc.MediaType, "architectures", architectures)
+
+	report := &imagebuilder.BuildReport{
+		Status:        "Succeeded",
+		IsPushed:      true,
+		Name:          o.name,
+		Images:        imageReferences(o.Registry, o.name, parsedTags),
+		Digest:        desc.Digest.String(),
+		Tags:          tagValues(parsedTags),
+		RegistryURL:   o.Registry[0],
+		Architectures: architectures,
+		Registries:    o.Registry,
+	}
+
+	for _, registry := range o.Registry {
+		err = b.copyImage(desc, registry+"/"+o.name, tagValues(parsedTags), opts)
+		if err != nil {
+			fmt.Printf("Image promotion to %s failed, err: %s\n", registry, err)
+			report.Status = "Failed"
+			report.IsPushed = false
+			return &BuildResult{Status: BuildStatusFailed, Report: report}, nil
+		}
+	}
+	fmt.Printf("Image %s promoted to: %v\n", desc.Digest, report.Images)
+
+	if o.promoteSign {
+		// Sign images pinned to the promoted digest, so signers can't sign a tag that was moved in the meantime
+		if err := b.sign(&o, pinnedReferences(report.Images, report.Digest)); err != nil {
+			fmt.Printf("Signing promoted images failed, err: %s\n", err)
+			report.Status = "Failed"
+			return &BuildResult{Status: BuildStatusFailed, Report: report}, nil
+		}
+		report.IsSigned = true
+	}
+	logger.Debugw("Image promoted", "buildReport", report)
+
+	return &BuildResult{Status: BuildStatusSucceeded, Report: report}, nil
+}
+
+// promoteTags returns tags of the promoted image.
+// If tags are provided with the --tag flag, only they are used, without the default and tag policy tags,
+// so promoting the image doesn't depend on the git state and works outside of CI.
+func promoteTags(logger Logger, o options) ([]tags.Tag, error) {
+	if len(o.tags) == 0 {
+		return parseTags(logger, o)
+	}
+	pr, sha, taggerOptions := tagTemplateContext(logger, o)
+	return getTags(logger, pr, sha, o.tags, taggerOptions...)
+}
+
+// copyImage writes the image by digest to the repository and tags it with all tags.
+// It returns an error if any tag doesn't point to the source digest after the copy.
+func (b *promoteBackend) copyImage(desc *remote.Descriptor, repository string, tagValues []string, opts []remote.Option) error {
+	repo, err := name.NewRepository(repository)
+	if err != nil {
+		return fmt.Errorf("invalid target repository %s: %w", repository, err)
+	}
+	target := repo.Digest(desc.Digest.String())
+
+	if desc.MediaType.IsIndex() {
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return fmt.Errorf("failed reading source image index: %w", err)
+		}
+		if err := remote.WriteIndex(target, idx, opts...); err != nil {
+			return fmt.Errorf("failed writing image index to %s: %w", target, err)
+		}
+	} else {
+		img, err := desc.Image()
+		if err != nil {
+			return fmt.Errorf("failed reading source image: %w", err)
+		}
+		if err := remote.Write(target, img, opts...); err != nil {
+			return fmt.Errorf("failed writing image to %s: %w", target, err)
+		}
+	}
+
+	for _, value := range tagValues {
+		tag := repo.Tag(value)
+		if err := remote.Tag(tag, desc, opts...); err != nil {
+			return fmt.Errorf("failed tagging image %s: %w", tag, err)
+		}
+		promoted, err := remote.Head(tag, opts...)
+		if err != nil {
+			return fmt.Errorf("failed reading promoted image %s: %w", tag, err)
+		}
+		if promoted.Digest != desc.Digest {
+			return fmt.Errorf("promoted image %s has digest %s, expected %s", tag, promoted.Digest, desc.Digest)
+		}
+	}
+	return nil
+}
+
+// pinnedReferences returns tag and digest references of the images, e.g. image:1.0.0@sha256:...
+func pinnedReferences(images []string, digest string) []string {
+	var pinned []string
+	for _, image := range images {
+		pinned = append(pinned, image+"@"+digest)
+	}
+	return pinned
+}
+
+// descriptorPlatforms returns platforms of the image in the os/arch format.
+// For a multi-arch image, platforms of all images from the index are returned.
+func descriptorPlatforms(desc *remote.Descriptor) ([]string, error) {
+	if desc.MediaType.IsIndex() {
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return nil, err
+		}
+		manifest, err := idx.IndexManifest()
+		if err != nil {
+			return nil, err
+		}
+		var platforms []string
+		for _, m := range manifest.Manifests {
+			if m.Platform != nil {
+				platforms = append(platforms, platformString(m.Platform))
+			}
+		}
+		return platforms, nil
+	}
+	img, err := desc.Image()
+	if err != nil {
+		return nil, err
+	}
+	config, err := img.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	if config.OS == "" {
+		return nil, nil
+	}
+	return []string{platformString(config.Platform())}, nil
+}
+
+// platformString returns the platform in the os/arch[/variant] format used by the --platform flag.
+func platformString(p *v1.Platform) string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/kyma-project/test-infra/pkg/sets"
+	"github.com/kyma-project/test-infra/pkg/tags"
+	"go.uber.org/zap"
+)
+
+// newTestRegistry starts the in-process registry and returns its host.
+func newTestRegistry(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+// pushMultiArchImage pushes the random linux/amd64 and linux/arm64 image index to the reference.
+func pushMultiArchImage(t *testing.T, ref string) v1.Hash {
+	t.Helper()
+	var idx v1.ImageIndex = empty.Index
+	for _, arch := range []string{"amd64", "arm64"} {
+		img, err := random.Image(256, 1)
+		if err != nil {
+			t.Fatalf("failed creating random image: %v", err)
+		}
+		idx = mutate.AppendManifests(idx, mutate.IndexAddendum{
+			Add:        img,
+			Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: arch}},
+		})
+	}
+	tag, err := name.NewTag(ref)
+	if err != nil {
+		t.Fatalf("invalid reference %s: %v", ref, err)
+	}
+	if err := remote.WriteIndex(tag, idx); err != nil {
+		t.Fatalf("failed pushing image index: %v", err)
+	}
+	digest, err := idx.Digest()
+	if err != nil {
+		t.Fatalf("failed computing image index digest: %v", err)
+	}
+	return digest
+}
+
+// pushImage pushes the random linux/amd64 image to the reference.
+func pushImage(t *testing.T, ref string) v1.Hash {
+	t.Helper()
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatalf("failed creating random image: %v", err)
+	}
+	img, err = mutate.ConfigFile(img, &v1.ConfigFile{OS: "linux", Architecture: "amd64"})
+	if err != nil {
+		t.Fatalf("failed setting image platform: %v", err)
+	}
+	tag, err := name.NewTag(ref)
+	if err != nil {
+		t.Fatalf("invalid reference %s: %v", ref, err)
+	}
+	if err := remote.Write(tag, img); err != nil {
+		t.Fatalf("failed pushing image: %v", err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatalf("failed computing image digest: %v", err)
+	}
+	return digest
+}
+
+func Test_promoteBackend_Build(t *testing.T) {
+	dev := newTestRegistry(t)
+	prod := newTestRegistry(t)
+	mirror := newTestRegistry(t)
+	multiArchDigest := pushMultiArchImage(t, dev+"/dev/test-image:PR-123")
+	singleArchDigest := pushImage(t, dev+"/dev/single-image:PR-123")
+
+	tc := []struct {
+		name           string
+		source         string
+		imageName      string
+		registries     Registry
+		sign           bool
+		signErr        error
+		expectedStatus string
+		expectedDigest v1.Hash
+		expectedArchs  []string
+		expectedSigned []string
+		expectErr      bool
+	}{
+		{
+			name:           "multi-arch image referenced by tag is promoted to all registries",
+			source:         dev + "/dev/test-image:PR-123",
+			imageName:      "test-image",
+			registries:     Registry{prod + "/prod", mirror + "/mirror"},
+			expectedStatus: BuildStatusSucceeded,
+			expectedDigest: multiArchDigest,
+			expectedArchs:  []string{"linux/amd64", "linux/arm64"},
+		},
+		{
+			name:           "multi-arch image referenced by digest is promoted and signed",
+			source:         dev + "/dev/test-image@" + multiArchDigest.String(),
+			imageName:      "signed-image",
+			registries:     Registry{prod + "/prod"},
+			sign:           true,
+			expectedStatus: BuildStatusSucceeded,
+			expectedDigest: multiArchDigest,
+			expectedArchs:  []string{"linux/amd64", "linux/arm64"},
+			expectedSigned: []string{prod + "/prod/signed-image:1.2.3@" + multiArchDigest.String()},
+		},
+		{
+			name:           "single platform image is promoted",
+			source:         dev + "/dev/single-image:PR-123",
+			imageName:      "single-image",
+			registries:     Registry{prod + "/prod"},
+			expectedStatus: BuildStatusSucceeded,
+			expectedDigest: singleArchDigest,
+			expectedArchs:  []string{"linux/amd64"},
+		},
+		{
+			name:           "signing failure fails the promotion",
+			source:         dev + "/dev/test-image:PR-123",
+			imageName:      "unsigned-image",
+			registries:     Registry{prod + "/prod"},
+			sign:           true,
+			signErr:        fmt.Errorf("signer unavailable"),
+			expectedStatus: BuildStatusFailed,
+			expectedDigest: multiArchDigest,
+			expectedArchs:  []string{"linux/amd64", "linux/arm64"},
+			expectedSigned: []string{prod + "/prod/unsigned-image:1.2.3@" + multiArchDigest.String()},
+		},
+		{
+			name:       "source image not found",
+			source:     dev + "/dev/test-image:missing",
+			imageName:  "test-image",
+			registries: Registry{prod + "/prod"},
+			expectErr:  true,
+		},
+		{
+			name:       "no registry configured",
+			source:     dev + "/dev/test-image:PR-123",
+			imageName:  "test-image",
+			registries: nil,
+			expectErr:  true,
+		},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			var signed []string
+			backend := &promoteBackend{sign: func(o *options, images []string) error {
+				signed = append(signed, images...)
+				return c.signErr
+			}}
+			o := options{
+				Config: Config{
+					Registry:    c.registries,
+					TagPolicies: TagPolicies{"postsubmit": {{Tags: []tags.Tag{{Name: "release", Value: "1.2.3"}}}}},
+				},
+				logger:        zap.NewNop().Sugar(),
+				gitState:      GitStateConfig{JobType: "postsubmit", BaseCommitSHA: "f1c7ca0b532141898f56c1843ae60ebec3a75a85"},
+				name:          c.imageName,
+				command:       PromoteCommand,
+				promoteSource: c.source,
+				promoteSign:   c.sign,
+			}
+
+			result, err := backend.Build(context.Background(), o)
+			if err != nil && !c.expectErr {
+				t.Fatalf("got error but didn't want to: %v", err)
+			}
+			if err == nil && c.expectErr {
+				t.Fatalf("didn't get error but wanted to")
+			}
+			if c.expectErr {
+				return
+			}
+
+			if result.Status != c.expectedStatus {
+				t.Errorf("Build() status = %s, expected %s", result.Status, c.expectedStatus)
+			}
+			report := result.Report
+			if report.Digest != c.expectedDigest.String() {
+				t.Errorf("report digest = %s, expected %s", report.Digest, c.expectedDigest)
+			}
+			if !reflect.DeepEqual(report.Architectures, c.expectedArchs) {
+				t.Errorf("report architectures = %v, expected %v", report.Architectures, c.expectedArchs)
+			}
+			if !reflect.DeepEqual(signed, c.expectedSigned) {
+				t.Errorf("signed images = %v, expected %v", signed, c.expectedSigned)
+			}
+			if report.IsSigned != (c.sign && c.signErr == nil) {
+				t.Errorf("report signed = %t, expected %t", report.IsSigned, c.sign && c.signErr == nil)
+			}
+
+			var expectedImages []string
+			for _, registry := range c.registries {
+				expectedImages = append(expectedImages, registry+"/"+c.imageName+":1.2.3")
+			}
+			if !reflect.DeepEqual(report.Images, expectedImages) {
+				t.Errorf("report images = %v, expected %v", report.Images, expectedImages)
+			}
+			for _, image := range expectedImages {
+				ref, err := name.ParseReference(image)
+				if err != nil {
+					t.Fatalf("invalid promoted image reference %s: %v", image, err)
+				}
+				desc, err := remote.Head(ref)
+				if err != nil {
+					t.Fatalf("failed reading promoted image %s: %v", image, err)
+				}
+				if desc.Digest != c.expectedDigest {
+					t.Errorf("promoted image %s has digest %s, expected %s", image, desc.Digest, c.expectedDigest)
+				}
+			}
+		})
+	}
+}
+
+func Test_promoteBackend_Build_outside_CI(t *testing.T) {
+	// Promoting doesn't read the git state, so it must work without CI environment variables
+	t.Setenv("CI", "")
+	t.Setenv("JOB_TYPE", "")
+	t.Setenv("GITHUB_EVENT_NAME", "")
+	dev := newTestRegistry(t)
+	prod := newTestRegistry(t)
+	digest := pushImage(t, dev+"/dev/test-image:PR-123")
+
+	tc := []struct {
+		name      string
+		tags      sets.Tags
+		expected  []string
+		expectErr bool
+	}{
+		{
+			name:     "explicit tags are used without tag policy tags",
+			tags:     sets.Tags{{Name: "release", Value: "1.2.3"}, {Name: "latest", Value: "latest"}},
+			expected: []string{prod + "/prod/test-image:1.2.3", prod + "/prod/test-image:latest"},
+		},
+		{
+			name:      "tag policy tags need the git state",
+			expectErr: true,
+		},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			o := options{
+				Config: Config{
+					Registry:    Registry{prod + "/prod"},
+					TagPolicies: TagPolicies{"postsubmit": {{Tags: []tags.Tag{{Name: "policy", Value: "{{ .CommitSHA }}"}}}}},
+				},
+				logger:        zap.NewNop().Sugar(),
+				name:          "test-image",
+				command:       PromoteCommand,
+				promoteSource: dev + "/dev/test-image@" + digest.String(),
+				tags:          c.tags,
+			}
+
+			result, err := (&promoteBackend{}).Build(context.Background(), o)
+			if err != nil && !c.expectErr {
+				t.Fatalf("got error but didn't want to: %v", err)
+			}
+			if err == nil && c.expectErr {
+				t.Fatalf("didn't get error but wanted to")
+			}
+			if c.expectErr {
+				return
+			}
+			if result.Status != BuildStatusSucceeded {
+				t.Errorf("Build() status = %s, expected %s", result.Status, BuildStatusSucceeded)
+			}
+			if !reflect.DeepEqual(result.Report.Images, c.expected) {
+				t.Errorf("report images = %v, expected %v", result.Report.Images, c.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/kyma-project/test-infra/pkg/tags"
+)
+
+// tagPolicyKeyTag is the tag policy key of jobs running for git tags, e.g. tag pushes and releases.
+const tagPolicyKeyTag = "tag"
+
+// tagPolicyKeys are keys allowed in the tag-policies config section.
+var tagPolicyKeys = []string{"presubmit", "postsubmit", "merge_group", "schedule", "workflow_dispatch", tagPolicyKeyTag}
+
+// TagPolicies maps job types to tag policies. Policies of the job type are evaluated in order,
+// and tags of the first policy matching the git ref are used.
+type TagPolicies map[string][]TagPolicy
+
+// TagPolicy defines tags of images built by jobs of a job type.
+type TagPolicy struct {
+	// Ref is a regular expression matched against the git ref the job runs for, e.g. ^refs/heads/release-.*$
+	// The git ref is the base ref of the pull request for presubmit jobs.
+	// If it's empty, the policy matches all refs.
+	Ref string `yaml:"ref,omitempty" json:"ref,omitempty"`
+	// Tags are tag templates used for images built by jobs matching the policy.
+	// See tags.Tag struct for more information and available fields
+	Tags []tags.Tag `yaml:"tags" json:"tags"`
+}
+
+// matches returns true if the policy applies to the git ref.
+func (p TagPolicy) matches(ref string) (bool, error) {
+	if p.Ref == "" {
+		return true, nil
+	}
+	re, err := regexp.Compile(p.Ref)
+	if err != nil {
+		return false, fmt.Errorf("invalid ref regex %s: %w", p.Ref, err)
+	}
+	return re.MatchString(ref), nil
+}
+
+// effectiveTagPolicies returns tag policies from the tag-policies config section.
+// Job types not defined in the section use policies built from the default tag fields, which keeps older configs working.
+func (c Config) effectiveTagPolicies() TagPolicies {
+	policies := TagPolicies{}
+	legacy := map[string][]tags.Tag{
+		// The additional PR tag is added before the default tag, as it was when the tags were set with separate fields
+		"presubmit":         {c.AdditionalPRTag, c.DefaultPRTag},
+		"postsubmit":        {c.DefaultCommitTag},
+		"merge_group":       {c.DefaultMergeGroupTag},
+		"schedule":          {c.DefaultCommitTag},
+		"workflow_dispatch": {c.DefaultCommitTag},
+		tagPolicyKeyTag:     {c.DefaultTagPushTag},
+	}
+	if c.DefaultTagPushTag == (tags.Tag{}) {
+		legacy[tagPolicyKeyTag] = []tags.Tag{defaultTagPushTag}
+	}
+	for key, legacyTags := range legacy {
+		var policyTags []tags.Tag
+		for _, tag := range legacyTags {
+			if len(tag.Value) > 0 {
+				policyTags = append(policyTags, tag)
+			}
+		}
+		if len(policyTags) > 0 {
+			policies[key] = []TagPolicy{{Tags: policyTags}}
+		}
+	}
+
+	for key, configured := range c.TagPolicies {
+		policies[key] = configured
+	}
+	return policies
+}
+
+// tagPolicyKey returns the tag policy key for the git state.
+func tagPolicyKey(gitState GitStateConfig) (string, error) {
+	switch {
+	case gitState.JobType == "merge_group":
+		return "merge_group", nil
+	case gitState.isPullRequest && gitState.PullRequestNumber > 0:
+		return "presubmit", nil
+	case gitState.IsTag():
+		return tagPolicyKeyTag, nil
+	case len(gitState.BaseCommitSHA) > 0:
+		// Scheduled and on-demand jobs build the commit, other jobs are handled as postsubmits
+		if gitState.JobType == "schedule" || gitState.JobType == "workflow_dispatch" {
+			return gitState.JobType, nil
+		}
+		return "postsubmit", nil
+	default:
+		return "", fmt.Errorf("could not determine tag policy, no pr number or commit sha provided")
+	}
+}
+
+// getPolicyTags returns tag templates of the tag policy matching the read git state.
+func getPolicyTags(logger Logger, o options) ([]tags.Tag, error) {
+	logger.Debugw("reading gitstate data")
+	key, err := tagPolicyKey(o.gitState)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debugw("tag policy key determined", "key", key, "ref", o.gitState.BaseCommitRef)
+
+	for i, policy := range o.effectiveTagPolicies()[key] {
+		matches, err := policy.matches(o.gitState.BaseCommitRef)
+		if err != nil {
+			return nil, fmt.Errorf("tag policy %s at index %d: %w", key, i, err)
+		}
+		if matches {
+			logger.Debugw("tag policy matched", "key", key, "index", i, "tags", policy.Tags)
+			return policy.Tags, nil
+		}
+	}
+	return nil, fmt.Errorf("no tag policy for %s matches ref %q, please define it in tag-policies config", key, o.gitState.BaseCommitRef)
+}
+
+// validate checks keys of tag policies are supported and all ref regexes and tag templates are valid.
+func (p TagPolicies) validate() []error {
+	var errs []error
+	keys := make([]string, 0, len(p))
+	for key := range p {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if !slices.Contains(tagPolicyKeys, key) {
+			errs = append(errs, fmt.Errorf("tag-policies: unsupported key %s, supported keys: %v", key, tagPolicyKeys))
+			continue
+		}
+		for i, policy := range p[key] {
+			if _, err := policy.matches(""); err != nil {
+				errs = append(errs, fmt.Errorf("tag-policies: %s[%d]: %w", key, i, err))
+			}
+			if len(policy.Tags) == 0 {
+				errs = append(errs, fmt.Errorf("tag-policies: %s[%d]: at least one tag is required", key, i))
+			}
+			for _, tag := range policy.Tags {
+				if err := tag.Validate(); err != nil {
+					errs = append(errs, fmt.Errorf("tag-policies: %s[%d]: %w", key, i, err))
+				}
+			}
+		}
+	}
+	return errs
+}
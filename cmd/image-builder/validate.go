@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	adoPipelines "github.com/kyma-project/test-infra/pkg/azuredevops/pipelines"
+	"github.com/kyma-project/test-infra/pkg/sign"
+	"github.com/kyma-project/test-infra/pkg/tags"
+	"gopkg.in/yaml.v3"
+	errutil "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// defaultLogFormat is the format of docker buildx logs used when log-format is not set in the config.
+const defaultLogFormat = "color"
+
+// supportedLogFormats are values allowed in the log-format config field.
+var supportedLogFormats = []string{"color", "text", "json"}
+
+// Validate checks the whole configuration and returns all found problems as one aggregated error.
+// It compiles all tag templates and validation regexes, checks signers referenced in enabled-signers and required-signers exist,
+// and checks the configuration required by the selected build backend is complete.
+// Tags which are not set are not validated, because the configuration may be used only for some job types.
+func (c Config) Validate() error {
+	var errs []error
+
+	configTags := []struct {
+		field string
+		tag   tags.Tag
+	}{
+		{"default-commit-tag", c.DefaultCommitTag},
+		{"default-pr-tag", c.DefaultPRTag},
+		{"default-merge-group-tag", c.DefaultMergeGroupTag},
+		{"default-tag-push-tag", c.DefaultTagPushTag},
+		{"additional-pr-tag", c.AdditionalPRTag},
+	}
+	for _, ct := range configTags {
+		if ct.tag == (tags.Tag{}) {
+			continue
+		}
+		if err := ct.tag.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ct.field, err))
+		}
+	}
+	errs = append(errs, c.TagPolicies.validate()...)
+	if err := c.TagEnv.Allow.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("tag-env: allow: %w", err))
+	}
+	if err := c.TagEnv.Sensitive.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("tag-env: sensitive: %w", err))
+	}
+
+	if c.LogFormat != "" && !slices.Contains(supportedLogFormats, c.LogFormat) {
+		errs = append(errs, fmt.Errorf("log-format: unsupported format %s, supported formats: %v", c.LogFormat, supportedLogFormats))
+	}
+
+	if c.Cache.Enabled && c.Cache.CacheRepo == "" {
+		errs = append(errs, fmt.Errorf("cache: cache-repo is required when cache is enabled"))
+	}
+
+	switch c.BuildBackend {
+	case "", ADOBackend:
+		errs = append(errs, validateADOConfig(c.AdoConfig)...)
+	case LocalBackend:
+		if len(c.Registry) == 0 {
+			errs = append(errs, fmt.Errorf("registry: at least one registry is required by the %s build backend", LocalBackend))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("build-backend: unknown build backend %s, supported backends: %s, %s", c.BuildBackend, ADOBackend, LocalBackend))
+	}
+
+	errs = append(errs, c.SignConfig.validate()...)
+
+	if c.GenericCI != nil {
+		errs = append(errs, c.GenericCI.Validate()...)
+	}
+
+	return errutil.NewAggregate(errs)
+}
+
+// validateADOConfig checks all fields required to trigger and follow the ADO pipeline run are set.
+func validateADOConfig(c adoPipelines.Config) []error {
+	var errs []error
+	if c.ADOOrganizationURL == "" {
+		errs = append(errs, fmt.Errorf("ado-config: ado-organization-url is required"))
+	}
+	if c.ADOProjectName == "" {
+		errs = append(errs, fmt.Errorf("ado-config: ado-project-name is required"))
+	}
+	if c.ADOPipelineID <= 0 {
+		errs = append(errs, fmt.Errorf("ado-config: ado-pipeline-id is required"))
+	}
+	// retry-go retries until success when attempts are set to 0
+	if c.ADORetryStrategy.Attempts == 0 {
+		errs = append(errs, fmt.Errorf("ado-config: ado-retry-strategy.attempts must be greater than 0"))
+	}
+	if c.ADORefreshInterval <= 0 {
+		errs = append(errs, fmt.Errorf("ado-config: ado-refresh-interval must be greater than 0"))
+	}
+	if c.ADOTimeout < 0 {
+		errs = append(errs, fmt.Errorf("ado-config: ado-timeout can't be negative"))
+	}
+	return errs
+}
+
+// validate checks signers have unique names and supported types, all signers referenced in enabled-signers are defined,
+// and all signers referenced in required-signers are defined and support verification.
+func (c SignConfig) validate() []error {
+	var errs []error
+	defined := make(map[string]bool)
+	for i, sc := range c.Signers {
+		if sc.Name == "" {
+			errs = append(errs, fmt.Errorf("sign-config: signer at index %d has no name", i))
+			continue
+		}
+		if defined[sc.Name] {
+			errs = append(errs, fmt.Errorf("sign-config: signer %s is defined more than once", sc.Name))
+		}
+		defined[sc.Name] = true
+		if !slices.Contains(sign.RegisteredTypes(), sc.Type) {
+			errs = append(errs, fmt.Errorf("sign-config: signer %s has unsupported type %s, supported types: %v", sc.Name, sc.Type, sign.RegisteredTypes()))
+		}
+	}
+
+	orgRepos := make([]string, 0, len(c.EnabledSigners))
+	for orgRepo := range c.EnabledSigners {
+		orgRepos = append(orgRepos, orgRepo)
+	}
+	slices.Sort(orgRepos)
+	for _, orgRepo := range orgRepos {
+		for _, name := range c.EnabledSigners[orgRepo] {
+			if !defined[name] {
+				errs = append(errs, fmt.Errorf("sign-config: signer %s enabled for %s is not defined in signers", name, orgRepo))
+			}
+		}
+	}
+
+	verifiable := make(map[string]bool)
+	for _, sc := range c.Signers {
+		if _, ok := sc.Config.(sign.VerifierFactory); ok {
+			verifiable[sc.Name] = true
+		}
+	}
+	orgRepos = orgRepos[:0]
+	for orgRepo := range c.RequiredSigners {
+		orgRepos = append(orgRepos, orgRepo)
+	}
+	slices.Sort(orgRepos)
+	for _, orgRepo := range orgRepos {
+		for _, name := range c.RequiredSigners[orgRepo] {
+			switch {
+			case !defined[name]:
+				errs = append(errs, fmt.Errorf("sign-config: signer %s required for %s is not defined in signers", name, orgRepo))
+			case !verifiable[name]:
+				errs = append(errs, fmt.Errorf("sign-config: signer %s required for %s doesn't support verification", name, orgRepo))
+			}
+		}
+	}
+	return errs
+}
+
+// runValidateConfig validates the configuration loaded from the config file and prints the result.
+// The build backend selected with the --backend flag takes precedence over the one from the config file.
+func runValidateConfig(o options, out io.Writer) error {
+	o.BuildBackend = getBuildBackendName(o)
+	if err := o.Config.Validate(); err != nil {
+		return fmt.Errorf("config %s is invalid: %w", o.configPath, err)
+	}
+	fmt.Fprintf(out, "Config %s is valid.\n", o.configPath)
+	return nil
+}
+
+// explainedConfig is the configuration effectively used by image-builder for a repository and job type,
+// with defaults applied.
+type explainedConfig struct {
+	Repository     string               `yaml:"repository"`
+	JobType        string               `yaml:"job-type"`
+	BuildBackend   string               `yaml:"build-backend"`
+	Registry       Registry             `yaml:"registry"`
+	DevRegistry    Registry             `yaml:"dev-registry"`
+	TagPolicies    []TagPolicy          `yaml:"tag-policies"`
+	TagEnv         TagEnvConfig         `yaml:"tag-env,omitempty"`
+	Cache          CacheConfig          `yaml:"cache"`
+	LogFormat      string               `yaml:"log-format"`
+	Reproducible   bool                 `yaml:"reproducible"`
+	Signers        []string             `yaml:"signers"`
+	IgnoredSigners []string             `yaml:"ignored-signers,omitempty"`
+	AdoConfig      *adoPipelines.Config `yaml:"ado-config,omitempty"`
+}
+
+// explainConfig returns the configuration effectively used for the repository and job type.
+func explainConfig(o options, orgRepo, jobType string) explainedConfig {
+	backend := getBuildBackendName(o)
+	explained := explainedConfig{
+		Repository:   orgRepo,
+		JobType:      jobType,
+		BuildBackend: backend,
+		Registry:     o.Registry,
+		DevRegistry:  o.DevRegistry,
+		TagEnv:       o.TagEnv,
+		Cache:        o.Cache,
+		LogFormat:    o.LogFormat,
+		Reproducible: o.Reproducible,
+		Signers:      []string{},
+	}
+	if len(explained.DevRegistry) == 0 {
+		explained.DevRegistry = o.Registry
+	}
+	if explained.LogFormat == "" {
+		explained.LogFormat = defaultLogFormat
+	}
+
+	// All policies of the job type are shown, because the matching one depends on the git ref
+	explained.TagPolicies = o.effectiveTagPolicies()[jobType]
+
+	// explain shows the configuration used in CI, where signers can be limited to job types
+	signers, ignored := enabledSignerConfigs(o.SignConfig, orgRepo, jobType, true)
+	for _, sc := range signers {
+		explained.Signers = append(explained.Signers, sc.Name)
+	}
+	explained.IgnoredSigners = ignored
+
+	if backend == ADOBackend {
+		adoConfig := o.AdoConfig.GetADOConfig()
+		explained.AdoConfig = &adoConfig
+	}
+	return explained
+}
+
+// runExplainConfig prints the configuration effectively used for the repository and job type as YAML.
+func runExplainConfig(o options, out io.Writer) error {
+	data, err := yaml.Marshal(explainConfig(o, o.orgRepo, o.explainJobType))
+	if err != nil {
+		return fmt.Errorf("failed marshalling explained config: %w", err)
+	}
+	_, err = out.Write(data)
+	return err
+}
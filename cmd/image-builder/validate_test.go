@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	adoPipelines "github.com/kyma-project/test-infra/pkg/azuredevops/pipelines"
+	"github.com/kyma-project/test-infra/pkg/sign"
+	"github.com/kyma-project/test-infra/pkg/tags"
+	errutil "k8s.io/apimachinery/pkg/util/errors"
+)
+
+var validADOConfig = adoPipelines.Config{
+	ADOOrganizationURL: "https://dev.azure.com/org",
+	ADOProjectName:     "project",
+	ADOPipelineID:      123,
+	ADORetryStrategy:   adoPipelines.RetryStrategy{Attempts: 3, Delay: 5 * time.Second},
+	ADORefreshInterval: 15 * time.Second,
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tc := []struct {
+		name           string
+		config         Config
+		expectedErrors int
+	}{
+		{
+			name: "valid ado config, pass",
+			config: Config{
+				AdoConfig: validADOConfig,
+				DefaultPRTag: tags.Tag{
+					Name:       "default_tag",
+					Value:      "PR-{{ .PRNumber }}",
+					Validation: "^(PR-[0-9]+)$",
+				},
+				SignConfig: SignConfig{
+					EnabledSigners: map[string][]string{"*": {"notary"}},
+					Signers:        []sign.SignerConfig{{Name: "notary", Type: sign.TypeNotaryBackend}},
+				},
+			},
+		},
+		{
+			name:   "valid local config, pass",
+			config: Config{BuildBackend: LocalBackend, Registry: Registry{"reg"}},
+		},
+		{
+			name: "all problems reported at once, fail",
+			config: Config{
+				AdoConfig: adoPipelines.Config{ADOOrganizationURL: "https://dev.azure.com/org", ADOProjectName: "project", ADOPipelineID: 123},
+				DefaultCommitTag: tags.Tag{
+					Name:       "default_tag",
+					Value:      "v{{ .Date }}",
+					Validation: "^(v[0-9]+$",
+				},
+				DefaultPRTag: tags.Tag{
+					Name:       "default_tag",
+					Value:      "PR-{{ .Missing }}",
+					Validation: "^(PR-[0-9]+)$",
+				},
+				TagPolicies: TagPolicies{
+					"postsubmit": {{Ref: "^(main$", Tags: []tags.Tag{{Name: "latest", Value: "latest"}}}},
+					"release":    {{Tags: []tags.Tag{{Name: "latest", Value: "latest"}}}},
+				},
+				LogFormat: "yaml",
+				Cache:     CacheConfig{Enabled: true},
+				SignConfig: SignConfig{
+					EnabledSigners: map[string][]string{"org/repo": {"missing-signer"}},
+					Signers: []sign.SignerConfig{
+						{Name: "notary", Type: sign.TypeNotaryBackend},
+						{Name: "notary", Type: "unknown"},
+					},
+				},
+			},
+			// commit tag regex, pr tag template, tag policy ref regex, tag policy key, log format, cache repo,
+			// retry attempts, refresh interval, duplicated signer, signer type, missing signer
+			expectedErrors: 11,
+		},
+		{
+			name: "required signers not defined or not verifiable, fail",
+			config: Config{
+				BuildBackend: LocalBackend,
+				Registry:     Registry{"europe-docker.pkg.dev/kyma-project/prod"},
+				SignConfig: SignConfig{
+					RequiredSigners: map[string][]string{"*": {"notary", "missing-signer"}},
+					Signers:         []sign.SignerConfig{{Name: "notary", Type: sign.TypeNotaryBackend}},
+				},
+			},
+			expectedErrors: 2,
+		},
+		{
+			name: "required signer supports verification",
+			config: Config{
+				BuildBackend: LocalBackend,
+				Registry:     Registry{"europe-docker.pkg.dev/kyma-project/prod"},
+				SignConfig: SignConfig{
+					RequiredSigners: map[string][]string{"*": {"notary"}},
+					Signers:         []sign.SignerConfig{{Name: "notary", Type: sign.TypeNotaryBackend, Config: &sign.NotaryConfig{}}},
+				},
+			},
+		},
+		{
+			name:           "local backend without registry, fail",
+			config:         Config{BuildBackend: LocalBackend},
+			expectedErrors: 1,
+		},
+		{
+			name:           "unknown backend, fail",
+			config:         Config{BuildBackend: "kaniko"},
+			expectedErrors: 1,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.config.Validate()
+			if c.expectedErrors == 0 {
+				if err != nil {
+					t.Errorf("got unexpected error: %s", err)
+				}
+				return
+			}
+			agg, ok := err.(errutil.Aggregate)
+			if !ok {
+				t.Fatalf("expected aggregated error, got %v", err)
+			}
+			if len(agg.Errors()) != c.expectedErrors {
+				t.Errorf("expected %d errors, got %d: %s", c.expectedErrors, len(agg.Errors()), err)
+			}
+		})
+	}
+}
+
+func Test_explainConfig(t *testing.T) {
+	o := options{Config: Config{
+		AdoConfig:        validADOConfig,
+		Registry:         Registry{"reg"},
+		DefaultCommitTag: tags.Tag{Name: "default_tag", Value: "v{{ .Date }}", Validation: "^v[0-9]+$"},
+		DefaultPRTag:     tags.Tag{Name: "default_tag", Value: "PR-{{ .PRNumber }}", Validation: "^PR-[0-9]+$"},
+		AdditionalPRTag:  tags.Tag{Name: "semver_pr_tag", Value: "v{{ .PRNumber }}-PR"},
+		SignConfig: SignConfig{
+			EnabledSigners: map[string][]string{"*": {"notary"}, "org/repo": {"repo-notary"}},
+			Signers: []sign.SignerConfig{
+				{Name: "notary", Type: sign.TypeNotaryBackend},
+				{Name: "repo-notary", Type: sign.TypeNotaryBackend, JobType: []string{"postsubmit"}},
+			},
+		},
+	}}
+
+	got := explainConfig(o, "org/repo", "presubmit")
+
+	expected := explainedConfig{
+		Repository:     "org/repo",
+		JobType:        "presubmit",
+		BuildBackend:   ADOBackend,
+		Registry:       Registry{"reg"},
+		DevRegistry:    Registry{"reg"},
+		TagPolicies:    []TagPolicy{{Tags: []tags.Tag{o.AdditionalPRTag, o.DefaultPRTag}}},
+		LogFormat:      defaultLogFormat,
+		Signers:        []string{"notary"},
+		IgnoredSigners: []string{"signer repo-notary ignored, because is not enabled for a CI job of type: presubmit"},
+		AdoConfig:      &validADOConfig,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("explainConfig(): got %+v, want %+v", got, expected)
+	}
+
+	// Git tag builds use the tag policy of the tag job type
+	tagPolicies := explainConfig(o, "org/repo", tagPolicyKeyTag).TagPolicies
+	if expected := []TagPolicy{{Tags: []tags.Tag{defaultTagPushTag}}}; !reflect.DeepEqual(tagPolicies, expected) {
+		t.Errorf("explainConfig() tag policies of git tag builds: got %+v, want %+v", tagPolicies, expected)
+	}
+
+	var out bytes.Buffer
+	o.orgRepo = "org/repo"
+	o.explainJobType = "postsubmit"
+	if err := runExplainConfig(o, &out); err != nil {
+		t.Fatalf("got unexpected error: %s", err)
+	}
+	for _, expectedLine := range []string{"tag-policies:", "value: v{{ .Date }}", "- repo-notary", "ado-refresh-interval: 15s"} {
+		if !strings.Contains(out.String(), expectedLine) {
+			t.Errorf("explain output doesn't contain %q:\n%s", expectedLine, out.String())
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kyma-project/test-infra/pkg/sign"
+)
+
+// Enum of signature statuses reported by the verify-only mode.
+const (
+	// SignatureStatusVerified means the image has a valid signature of the signer.
+	SignatureStatusVerified = "verified"
+	// SignatureStatusMissing means the image has no valid signature of the signer.
+	SignatureStatusMissing = "missing"
+	// SignatureStatusError means the signature couldn't be checked, e.g. the registry is not reachable.
+	SignatureStatusError = "error"
+)
+
+// VerifyReport is the result of checking signatures of images in the verify-only mode.
+type VerifyReport struct {
+	// Verified is true if all images have valid signatures of all required signers
+	Verified bool `json:"verified"`
+	// Images holds the result for each image, in the order images were provided
+	Images []ImageVerification `json:"images"`
+}
+
+// ImageVerification holds signature statuses of the image for each required signer.
+type ImageVerification struct {
+	// Image is the verified image reference
+	Image string `json:"image"`
+	// Verified is true if the image has valid signatures of all required signers
+	Verified bool `json:"verified"`
+	// Signatures holds the signature status for each required signer
+	Signatures []SignatureVerification `json:"signatures"`
+}
+
+// SignatureVerification is the status of the signature of a single signer.
+type SignatureVerification struct {
+	// Signer is the name of the signer from the sign-config section
+	Signer string `json:"signer"`
+	// Status is one of SignatureStatusVerified, SignatureStatusMissing or SignatureStatusError
+	Status string `json:"status"`
+	// Error describes why the signature is missing or couldn't be checked
+	Error string `json:"error,omitempty"`
+}
+
+// namedVerifier is the verifier of the signer with the given name.
+type namedVerifier struct {
+	name     string
+	verifier sign.Verifier
+}
+
+// requiredSignerConfigs returns configurations of signers whose signatures are required for images of the repository.
+// Signers required for '*' are required for all repositories.
+// If no signers are required in the config, signers enabled for the repository are required, regardless of job types.
+func requiredSignerConfigs(c SignConfig, orgRepo string) ([]sign.SignerConfig, error) {
+	policy := c.RequiredSigners
+	if len(policy) == 0 {
+		policy = c.EnabledSigners
+	}
+	var required StrList
+	for _, s := range append(policy["*"], policy[orgRepo]...) {
+		required.Add(s)
+	}
+	var configs []sign.SignerConfig
+	for _, sc := range c.Signers {
+		if required.Has(sc.Name) {
+			configs = append(configs, sc)
+		}
+	}
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no signers are required for %s, nothing to verify", orgRepo)
+	}
+	return configs, nil
+}
+
+// newVerifiers creates verifiers for the signer configurations.
+// It returns an error if the signer type doesn't support verification.
+func newVerifiers(configs []sign.SignerConfig) ([]namedVerifier, error) {
+	var verifiers []namedVerifier
+	for _, sc := range configs {
+		factory, ok := sc.Config.(sign.VerifierFactory)
+		if !ok {
+			return nil, fmt.Errorf("signer %s of type %s doesn't support verification", sc.Name, sc.Type)
+		}
+		v, err := factory.NewVerifier()
+		if err != nil {
+			return nil, fmt.Errorf("verifier %s init: %w", sc.Name, err)
+		}
+		verifiers = append(verifiers, namedVerifier{name: sc.Name, verifier: v})
+	}
+	return verifiers, nil
+}
+
+// verifyImages checks signatures of all images with all verifiers.
+func verifyImages(verifiers []namedVerifier, images []string) *VerifyReport {
+	report := &VerifyReport{Verified: true}
+	for _, image := range images {
+		result := ImageVerification{Image: image, Verified: true}
+		for _, v := range verifiers {
+			signature := SignatureVerification{Signer: v.name, Status: SignatureStatusVerified}
+			if err := v.verifier.Verify(image); err != nil {
+				signature.Status = SignatureStatusError
+				if errors.Is(err, sign.ErrSignatureNotFound) {
+					signature.Status = SignatureStatusMissing
+				}
+				signature.Error = err.Error()
+				result.Verified = false
+			}
+			result.Signatures = append(result.Signatures, signature)
+		}
+		report.Verified = report.Verified && result.Verified
+		report.Images = append(report.Images, result)
+	}
+	return report
+}
+
+// printVerifyReport writes the signature status of each image and signer in a human-readable format.
+func printVerifyReport(w io.Writer, report *VerifyReport) {
+	for _, image := range report.Images {
+		fmt.Fprintln(w, image.Image)
+		for _, s := range image.Signatures {
+			if s.Error != "" {
+				fmt.Fprintf(w, "  %s: %s (%s)\n", s.Signer, s.Status, s.Error)
+				continue
+			}
+			fmt.Fprintf(w, "  %s: %s\n", s.Signer, s.Status)
+		}
+	}
+}
+
+// runVerifyImages checks that images provided with the --images-to-verify flag are signed by all required signers.
+// The report is printed and written as JSON to the --build-report-path file, if it's set.
+// It returns an error if any required signature is missing or couldn't be checked.
+func runVerifyImages(o *options, w io.Writer) error {
+	orgRepo, err := signOrgRepo(o)
+	if err != nil {
+		return err
+	}
+	configs, err := requiredSignerConfigs(o.SignConfig, orgRepo)
+	if err != nil {
+		return err
+	}
+	verifiers, err := newVerifiers(configs)
+	if err != nil {
+		return err
+	}
+
+	report := verifyImages(verifiers, o.imagesToVerify)
+	printVerifyReport(w, report)
+
+	if o.buildReportPath != "" {
+		data, err := json.Marshal(report)
+		if err != nil {
+			return fmt.Errorf("failed to marshal verify report: %w", err)
+		}
+		if err := os.WriteFile(o.buildReportPath, data, 0644); err != nil {
+			return fmt.Errorf("failed to write verify report to file: %w", err)
+		}
+	}
+
+	if !report.Verified {
+		return fmt.Errorf("required signatures are missing or couldn't be checked")
+	}
+	return nil
+}
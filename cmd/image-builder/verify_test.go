@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kyma-project/test-infra/pkg/sign"
+)
+
+// fakeVerifier returns the error set for the image.
+type fakeVerifier map[string]error
+
+func (f fakeVerifier) Verify(image string) error {
+	return f[image]
+}
+
+func Test_requiredSignerConfigs(t *testing.T) {
+	signers := []sign.SignerConfig{
+		{Name: "notary", Type: sign.TypeNotaryBackend},
+		{Name: "cosign", Type: sign.TypeCosignBackend},
+		{Name: "repo-cosign", Type: sign.TypeCosignBackend},
+	}
+	tc := []struct {
+		name      string
+		config    SignConfig
+		orgRepo   string
+		expected  []string
+		expectErr bool
+	}{
+		{
+			name: "required signers for all repositories and the repository",
+			config: SignConfig{
+				RequiredSigners: map[string][]string{"*": {"cosign"}, "org/repo": {"repo-cosign", "cosign"}},
+				EnabledSigners:  map[string][]string{"*": {"notary"}},
+				Signers:         signers,
+			},
+			orgRepo:  "org/repo",
+			expected: []string{"cosign", "repo-cosign"},
+		},
+		{
+			name: "enabled signers are required by default",
+			config: SignConfig{
+				EnabledSigners: map[string][]string{"*": {"notary"}, "org/other": {"cosign"}},
+				Signers:        signers,
+			},
+			orgRepo:  "org/repo",
+			expected: []string{"notary"},
+		},
+		{
+			name: "no required signers",
+			config: SignConfig{
+				RequiredSigners: map[string][]string{"org/other": {"cosign"}},
+				Signers:         signers,
+			},
+			orgRepo:   "org/repo",
+			expectErr: true,
+		},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			configs, err := requiredSignerConfigs(c.config, c.orgRepo)
+			if err != nil && !c.expectErr {
+				t.Errorf("got error but didn't want to: %v", err)
+			}
+			if err == nil && c.expectErr {
+				t.Errorf("didn't get error but wanted to")
+			}
+			var names []string
+			for _, sc := range configs {
+				names = append(names, sc.Name)
+			}
+			if !reflect.DeepEqual(names, c.expected) {
+				t.Errorf("requiredSignerConfigs() = %v, expected %v", names, c.expected)
+			}
+		})
+	}
+}
+
+func Test_verifyImages(t *testing.T) {
+	verifiers := []namedVerifier{
+		{name: "cosign", verifier: fakeVerifier{
+			"image:unsigned": fmt.Errorf("%w: no cosign signature", sign.ErrSignatureNotFound),
+		}},
+		{name: "notary", verifier: fakeVerifier{
+			"image:unsigned": fmt.Errorf("%w: tag unsigned is not signed", sign.ErrSignatureNotFound),
+			"image:partial":  fmt.Errorf("failed to fetch trust data: unexpected status code: 500"),
+		}},
+	}
+
+	report := verifyImages(verifiers, []string{"image:signed", "image:partial", "image:unsigned"})
+
+	expected := &VerifyReport{
+		Verified: false,
+		Images: []ImageVerification{
+			{Image: "image:signed", Verified: true, Signatures: []SignatureVerification{
+				{Signer: "cosign", Status: SignatureStatusVerified},
+				{Signer: "notary", Status: SignatureStatusVerified},
+			}},
+			{Image: "image:partial", Verified: false, Signatures: []SignatureVerification{
+				{Signer: "cosign", Status: SignatureStatusVerified},
+				{Signer: "notary", Status: SignatureStatusError, Error: "failed to fetch trust data: unexpected status code: 500"},
+			}},
+			{Image: "image:unsigned", Verified: false, Signatures: []SignatureVerification{
+				{Signer: "cosign", Status: SignatureStatusMissing, Error: "signature not found: no cosign signature"},
+				{Signer: "notary", Status: SignatureStatusMissing, Error: "signature not found: tag unsigned is not signed"},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("verifyImages() = %+v, expected %+v", report, expected)
+	}
+
+	if report := verifyImages(verifiers, []string{"image:signed"}); !report.Verified {
+		t.Errorf("expected signed image to be verified")
+	}
+}
+
+func Test_runVerifyImages(t *testing.T) {
+	host := newTestRegistry(t)
+	signed := host + "/prod/signed-image:1.0.0"
+	unsigned := host + "/prod/unsigned-image:1.0.0"
+	pushImage(t, signed)
+	pushImage(t, unsigned)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "cosign.key")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	cosignConfig := &sign.CosignConfig{KeyPath: keyPath}
+	signer, err := cosignConfig.NewSigner()
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	if err := signer.Sign([]string{signed}); err != nil {
+		t.Fatalf("failed to sign image: %v", err)
+	}
+
+	tc := []struct {
+		name      string
+		images    []string
+		expectErr bool
+	}{
+		{name: "all images signed", images: []string{signed}},
+		{name: "signature missing", images: []string{signed, unsigned}, expectErr: true},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			reportPath := filepath.Join(t.TempDir(), "report.json")
+			o := &options{
+				Config: Config{SignConfig: SignConfig{
+					RequiredSigners: map[string][]string{"*": {"cosign"}},
+					Signers:         []sign.SignerConfig{{Name: "cosign", Type: sign.TypeCosignBackend, Config: cosignConfig}},
+				}},
+				orgRepo:         "kyma-project/test-infra",
+				imagesToVerify:  c.images,
+				buildReportPath: reportPath,
+			}
+			var out bytes.Buffer
+			err := runVerifyImages(o, &out)
+			if err != nil && !c.expectErr {
+				t.Errorf("got error but didn't want to: %v", err)
+			}
+			if err == nil && c.expectErr {
+				t.Errorf("didn't get error but wanted to")
+			}
+
+			data, err := os.ReadFile(reportPath)
+			if err != nil {
+				t.Fatalf("failed to read report: %v", err)
+			}
+			var report VerifyReport
+			if err := json.Unmarshal(data, &report); err != nil {
+				t.Fatalf("failed to parse report: %v", err)
+			}
+			if report.Verified == c.expectErr {
+				t.Errorf("report verified = %t, expected %t", report.Verified, !c.expectErr)
+			}
+			if len(report.Images) != len(c.images) {
+				t.Fatalf("report has %d images, expected %d", len(report.Images), len(c.images))
+			}
+			for i, image := range report.Images {
+				expected := SignatureStatusVerified
+				if c.images[i] == unsigned {
+					expected = SignatureStatusMissing
+				}
+				if image.Signatures[0].Status != expected {
+					t.Errorf("signature status of %s = %s, expected %s", image.Image, image.Signatures[0].Status, expected)
+				}
+			}
+			if out.Len() == 0 {
+				t.Errorf("expected report to be printed")
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package pipelines
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/avast/retry-go/v5"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/build"
+)
+
+// timelineRecordTypeTask is the type of timeline records describing single steps of the ADO pipeline run.
+// Stage and job records have logs too, but they repeat the logs of their steps.
+const timelineRecordTypeTask = "Task"
+
+// LogStreamer follows the timeline of the ADO pipeline run and prints log lines of its steps as they appear.
+// Each printed line is prefixed with the name of the step which produced it.
+// All fetched lines are kept, so the complete log of the run is available when the run finishes.
+// LogStreamer is not safe for concurrent use.
+type LogStreamer struct {
+	buildClient BuildClient
+	adoConfig   Config
+	runID       *int
+	out         io.Writer
+	// lines holds log lines already fetched for each log ID
+	lines map[int][]string
+	// logIDs holds IDs of step logs in the order they are written out by Logs
+	logIDs []int
+}
+
+// NewLogStreamer creates the LogStreamer printing logs of the ADO pipeline run to out.
+func NewLogStreamer(buildClient BuildClient, adoConfig Config, pipelineRunID *int, out io.Writer) *LogStreamer {
+	return &LogStreamer{
+		buildClient: buildClient,
+		adoConfig:   adoConfig,
+		runID:       pipelineRunID,
+		out:         out,
+		lines:       make(map[int][]string),
+	}
+}
+
+// Poll fetches the timeline of the ADO pipeline run and prints lines added to step logs since the last call.
+// Steps are processed in the order their logs were created.
+// Requests are retried according to the retry strategy from the ADO configuration.
+func (s *LogStreamer) Poll(ctx context.Context) error {
+	timeline, err := retry.NewWithData[*build.Timeline](
+		retry.Attempts(s.adoConfig.ADORetryStrategy.Attempts),
+		retry.Delay(s.adoConfig.ADORetryStrategy.Delay),
+		retry.Context(ctx),
+	).Do(
+		func() (*build.Timeline, error) {
+			return s.buildClient.GetBuildTimeline(ctx, build.GetBuildTimelineArgs{
+				Project: &s.adoConfig.ADOProjectName,
+				BuildId: s.runID,
+			})
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed getting ADO pipeline run timeline, err: %w", err)
+	}
+	if timeline == nil || timeline.Records == nil {
+		return nil
+	}
+
+	for _, record := range stepRecords(*timeline.Records) {
+		if err := s.pollStep(ctx, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pollStep fetches and prints lines added to the log of a single step.
+func (s *LogStreamer) pollStep(ctx context.Context, record build.TimelineRecord) error {
+	logID := *record.Log.Id
+	fetched, known := s.lines[logID]
+	if !known {
+		s.logIDs = append(s.logIDs, logID)
+	}
+
+	args := build.GetBuildLogLinesArgs{
+		Project: &s.adoConfig.ADOProjectName,
+		BuildId: s.runID,
+		LogId:   &logID,
+	}
+	// Log lines are numbered from 1, ask only for lines not fetched yet.
+	if len(fetched) > 0 {
+		startLine := uint64(len(fetched) + 1)
+		args.StartLine = &startLine
+	}
+
+	newLines, err := retry.NewWithData[*[]string](
+		retry.Attempts(s.adoConfig.ADORetryStrategy.Attempts),
+		retry.Delay(s.adoConfig.ADORetryStrategy.Delay),
+		retry.Context(ctx),
+	).Do(
+		func() (*[]string, error) {
+			return s.buildClient.GetBuildLogLines(ctx, args)
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed getting log lines of step %s, err: %w", stepName(record), err)
+	}
+	if newLines == nil {
+		s.lines[logID] = fetched
+		return nil
+	}
+
+	for _, line := range *newLines {
+		fmt.Fprintf(s.out, "[%s] %s\n", stepName(record), line)
+	}
+	s.lines[logID] = append(fetched, *newLines...)
+	return nil
+}
+
+// Logs returns all fetched log lines of the ADO pipeline run without step name prefixes.
+// Logs of steps are joined in the order they were created.
+func (s *LogStreamer) Logs() string {
+	var sb strings.Builder
+	for _, logID := range s.logIDs {
+		for _, line := range s.lines[logID] {
+			sb.WriteString(line)
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// stepRecords returns timeline records of the pipeline run steps which have a log, sorted by log ID.
+// Log IDs are assigned in the order logs are created, so the order follows the progress of the run.
+func stepRecords(records []build.TimelineRecord) []build.TimelineRecord {
+	var steps []build.TimelineRecord
+	for _, record := range records {
+		if record.Type == nil || *record.Type != timelineRecordTypeTask {
+			continue
+		}
+		if record.Log == nil || record.Log.Id == nil {
+			continue
+		}
+		steps = append(steps, record)
+	}
+	sort.SliceStable(steps, func(i, j int) bool {
+		return *steps[i].Log.Id < *steps[j].Log.Id
+	})
+	return steps
+}
+
+// stepName returns the name of the step described by the timeline record.
+func stepName(record build.TimelineRecord) string {
+	if record.Name == nil {
+		return "unknown step"
+	}
+	return *record.Name
+}
@@ -0,0 +1,91 @@
+package pipelines_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kyma-project/test-infra/pkg/azuredevops/pipelines"
+	pipelinesMocks "github.com/kyma-project/test-infra/pkg/azuredevops/pipelines/mocks"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/build"
+	"k8s.io/utils/ptr"
+)
+
+var _ = Describe("LogStreamer", func() {
+	var (
+		ctx             context.Context
+		mockBuildClient *pipelinesMocks.MockBuildClient
+		adoConfig       pipelines.Config
+		out             *bytes.Buffer
+		streamer        *pipelines.LogStreamer
+		timelineArgs    build.GetBuildTimelineArgs
+		t               ginkgoT
+	)
+
+	logLinesArgs := func(logID int, startLine *uint64) build.GetBuildLogLinesArgs {
+		return build.GetBuildLogLinesArgs{
+			Project:   &adoConfig.ADOProjectName,
+			BuildId:   ptr.To(42),
+			LogId:     ptr.To(logID),
+			StartLine: startLine,
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		t = ginkgoT{}
+		t.GinkgoTInterface = GinkgoT()
+		mockBuildClient = pipelinesMocks.NewMockBuildClient(t)
+		adoConfig = pipelines.Config{
+			ADOProjectName: "example-project",
+			ADORetryStrategy: pipelines.RetryStrategy{
+				Attempts: 2,
+				Delay:    time.Millisecond,
+			},
+		}
+		out = &bytes.Buffer{}
+		streamer = pipelines.NewLogStreamer(mockBuildClient, adoConfig, ptr.To(42), out)
+		timelineArgs = build.GetBuildTimelineArgs{
+			Project: &adoConfig.ADOProjectName,
+			BuildId: ptr.To(42),
+		}
+	})
+
+	It("should print new lines of each step prefixed with the step name", func() {
+		timeline := &build.Timeline{Records: &[]build.TimelineRecord{
+			{Name: ptr.To("Build image"), Type: ptr.To("Task"), Log: &build.BuildLogReference{Id: ptr.To(5)}},
+			{Name: ptr.To("Job"), Type: ptr.To("Job"), Log: &build.BuildLogReference{Id: ptr.To(7)}},
+			{Name: ptr.To("Checkout"), Type: ptr.To("Task"), Log: &build.BuildLogReference{Id: ptr.To(3)}},
+			{Name: ptr.To("Sign image"), Type: ptr.To("Task")},
+		}}
+		mockBuildClient.On("GetBuildTimeline", ctx, timelineArgs).Return(timeline, nil)
+		mockBuildClient.On("GetBuildLogLines", ctx, logLinesArgs(3, nil)).Return(&[]string{"checkout done"}, nil).Once()
+		mockBuildClient.On("GetBuildLogLines", ctx, logLinesArgs(5, nil)).Return(&[]string{"step 1"}, nil).Once()
+		mockBuildClient.On("GetBuildLogLines", ctx, logLinesArgs(3, ptr.To(uint64(2)))).Return(&[]string{}, nil).Once()
+		mockBuildClient.On("GetBuildLogLines", ctx, logLinesArgs(5, ptr.To(uint64(2)))).Return(&[]string{"step 2", "step 3"}, nil).Once()
+
+		Expect(streamer.Poll(ctx)).To(Succeed())
+		Expect(streamer.Poll(ctx)).To(Succeed())
+
+		Expect(out.String()).To(Equal("[Checkout] checkout done\n[Build image] step 1\n[Build image] step 2\n[Build image] step 3\n"))
+		Expect(streamer.Logs()).To(Equal("checkout done\nstep 1\nstep 2\nstep 3\n"))
+		mockBuildClient.AssertNotCalled(t, "GetBuildLogLines", ctx, logLinesArgs(7, nil))
+		mockBuildClient.AssertExpectations(GinkgoT())
+	})
+
+	It("should handle ADO client error", func() {
+		mockBuildClient.On("GetBuildTimeline", ctx, timelineArgs).Return(nil, fmt.Errorf("ADO client error"))
+
+		err := streamer.Poll(ctx)
+
+		Expect(err).To(HaveOccurred())
+		Expect(out.String()).To(BeEmpty())
+		mockBuildClient.AssertNumberOfCalls(t, "GetBuildTimeline", 2)
+		mockBuildClient.AssertExpectations(GinkgoT())
+	})
+})
@@ -259,6 +259,65 @@ func (_c *MockBuildClient_GetBuilds_Call) RunAndReturn(run func(context.Context,
 	return _c
 }
 
+// UpdateBuild provides a mock function with given fields: ctx, args
+func (_m *MockBuildClient) UpdateBuild(ctx context.Context, args build.UpdateBuildArgs) (*build.Build, error) {
+	ret := _m.Called(ctx, args)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateBuild")
+	}
+
+	var r0 *build.Build
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, build.UpdateBuildArgs) (*build.Build, error)); ok {
+		return rf(ctx, args)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, build.UpdateBuildArgs) *build.Build); ok {
+		r0 = rf(ctx, args)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*build.Build)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, build.UpdateBuildArgs) error); ok {
+		r1 = rf(ctx, args)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockBuildClient_UpdateBuild_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateBuild'
+type MockBuildClient_UpdateBuild_Call struct {
+	*mock.Call
+}
+
+// UpdateBuild is a helper method to define mock.On call
+//   - ctx context.Context
+//   - args build.UpdateBuildArgs
+func (_e *MockBuildClient_Expecter) UpdateBuild(ctx interface{}, args interface{}) *MockBuildClient_UpdateBuild_Call {
+	return &MockBuildClient_UpdateBuild_Call{Call: _e.mock.On("UpdateBuild", ctx, args)}
+}
+
+func (_c *MockBuildClient_UpdateBuild_Call) Run(run func(ctx context.Context, args build.UpdateBuildArgs)) *MockBuildClient_UpdateBuild_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(build.UpdateBuildArgs))
+	})
+	return _c
+}
+
+func (_c *MockBuildClient_UpdateBuild_Call) Return(_a0 *build.Build, _a1 error) *MockBuildClient_UpdateBuild_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockBuildClient_UpdateBuild_Call) RunAndReturn(run func(context.Context, build.UpdateBuildArgs) (*build.Build, error)) *MockBuildClient_UpdateBuild_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockBuildClient creates a new instance of MockBuildClient. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockBuildClient(t interface {
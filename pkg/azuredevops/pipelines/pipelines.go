@@ -38,6 +38,7 @@ type BuildClient interface {
 	GetBuilds(ctx context.Context, args build.GetBuildsArgs) (*build.GetBuildsResponseValue, error)
 	GetBuildLogLines(ctx context.Context, args build.GetBuildLogLinesArgs) (*[]string, error)
 	GetBuildTimeline(ctx context.Context, args build.GetBuildTimelineArgs) (*build.Timeline, error)
+	UpdateBuild(ctx context.Context, args build.UpdateBuildArgs) (*build.Build, error)
 }
 
 type Tests struct {
@@ -85,6 +86,7 @@ type RetryStrategy struct {
 // ADOPipelineVersion: The version of the ADO pipeline.
 // ADORequestStrategy: Strategy for retrying failed requests to ADO API
 // ADORefreshInterval: Interval between two requests for ADO Pipelien status
+// ADOTimeout: Maximum time to wait for the ADO pipeline run to finish
 type Config struct {
 	// ADO organization URL to call for triggering ADO pipeline
 	ADOOrganizationURL string `yaml:"ado-organization-url" json:"ado-organization-url"`
@@ -98,6 +100,8 @@ type Config struct {
 	ADORetryStrategy RetryStrategy `yaml:"ado-retry-strategy" json:"ado-retry-strategy"`
 	// ADO Refresh Interval holds information about how often client should ask for status of ADO Pipeline
 	ADORefreshInterval time.Duration `yaml:"ado-refresh-interval" json:"ado-refresh-interval"`
+	// ADO Timeout holds information about how long client should wait for ADO Pipeline run to finish, zero means no timeout
+	ADOTimeout time.Duration `yaml:"ado-timeout,omitempty" json:"ado-timeout,omitempty"`
 }
 
 func (c Config) GetADOConfig() Config {
@@ -143,28 +147,19 @@ func NewBuildClientWithSP(ctx context.Context, organizationURL string, provider
 // The function returns the result of the pipeline run and an error. If the pipeline run is still in progress,
 // the function waits for the specified sleep duration before checking again. If an error occurs while getting
 // the pipeline run, the function returns the error. If the pipeline run is completed, the function returns
-// the result of the pipeline run.
+// the result of the pipeline run. If the context is done before the pipeline run completes,
+// the function stops waiting and returns the context error.
 func GetRunResult(ctx context.Context, adoClient Client, adoConfig Config, pipelineRunID *int) (*pipelines.RunResult, error) {
 	for {
 		// Sleep for the specified duration before checking the pipeline run state.
-		time.Sleep(adoConfig.ADORefreshInterval)
-		// Get the pipeline run. If an error occurs, retry three times with a delay of 5 seconds between each retry.
-		// We get the pipeline run status over network, so we need to handle network errors.
-		pipelineRun, err := retry.NewWithData[*pipelines.Run](
-			retry.Attempts(adoConfig.ADORetryStrategy.Attempts),
-			retry.Delay(adoConfig.ADORetryStrategy.Delay),
-		).Do(
-			func() (*pipelines.Run, error) {
-				pipelineRun, err := adoClient.GetRun(ctx, pipelines.GetRunArgs{
-					Project:    &adoConfig.ADOProjectName,
-					PipelineId: &adoConfig.ADOPipelineID,
-					RunId:      pipelineRunID,
-				})
-				return pipelineRun, err
-			},
-		)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("stopped waiting for ADO pipeline run: %w", ctx.Err())
+		case <-time.After(adoConfig.ADORefreshInterval):
+		}
+		pipelineRun, err := GetRun(ctx, adoClient, adoConfig, pipelineRunID)
 		if err != nil {
-			return nil, fmt.Errorf("failed getting ADO pipeline run, err: %w", err)
+			return nil, err
 		}
 		// If the pipeline run is completed, return the result of the pipeline run.
 		if *pipelineRun.State == pipelines.RunStateValues.Completed {
@@ -176,6 +171,54 @@ func GetRunResult(ctx context.Context, adoClient Client, adoConfig Config, pipel
 	}
 }
 
+// GetRun retrieves the current state of a specific ADO pipeline run without waiting for it to finish.
+// The request is retried according to the retry strategy from the ADO configuration.
+func GetRun(ctx context.Context, adoClient Client, adoConfig Config, pipelineRunID *int) (*pipelines.Run, error) {
+	// Get the pipeline run. If an error occurs, retry according to the retry strategy.
+	// We get the pipeline run status over network, so we need to handle network errors.
+	pipelineRun, err := retry.NewWithData[*pipelines.Run](
+		retry.Attempts(adoConfig.ADORetryStrategy.Attempts),
+		retry.Delay(adoConfig.ADORetryStrategy.Delay),
+		retry.Context(ctx),
+	).Do(
+		func() (*pipelines.Run, error) {
+			pipelineRun, err := adoClient.GetRun(ctx, pipelines.GetRunArgs{
+				Project:    &adoConfig.ADOProjectName,
+				PipelineId: &adoConfig.ADOPipelineID,
+				RunId:      pipelineRunID,
+			})
+			return pipelineRun, err
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed getting ADO pipeline run, err: %w", err)
+	}
+	return pipelineRun, nil
+}
+
+// CancelRun requests cancellation of a specific ADO pipeline run.
+// ADO pipeline runs are builds, so the run is cancelled by setting the status of the build to cancelling.
+// The request is retried according to the retry strategy from the ADO configuration.
+func CancelRun(ctx context.Context, buildClient BuildClient, adoConfig Config, pipelineRunID *int) error {
+	_, err := retry.NewWithData[*build.Build](
+		retry.Attempts(adoConfig.ADORetryStrategy.Attempts),
+		retry.Delay(adoConfig.ADORetryStrategy.Delay),
+		retry.Context(ctx),
+	).Do(
+		func() (*build.Build, error) {
+			return buildClient.UpdateBuild(ctx, build.UpdateBuildArgs{
+				Build:   &build.Build{Status: &build.BuildStatusValues.Cancelling},
+				Project: &adoConfig.ADOProjectName,
+				BuildId: pipelineRunID,
+			})
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed cancelling ADO pipeline run, err: %w", err)
+	}
+	return nil
+}
+
 // GetRunLogsWithBearerToken retrieves the logs of a specific ADO pipeline run using Bearer token authentication.
 func GetRunLogsWithBearerToken(ctx context.Context, buildClient BuildClient, httpClient HTTPClient, adoConfig Config, pipelineRunID *int, provider TokenProvider) (string, error) {
 	token, err := provider.GetToken(ctx)
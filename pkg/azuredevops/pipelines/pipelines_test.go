@@ -12,6 +12,7 @@ import (
 	"github.com/kyma-project/test-infra/pkg/azuredevops/pipelines"
 	pipelinesMocks "github.com/kyma-project/test-infra/pkg/azuredevops/pipelines/mocks"
 
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/build"
 	adoPipelines "github.com/microsoft/azure-devops-go-api/azuredevops/v7/pipelines"
 	"k8s.io/utils/ptr"
 )
@@ -102,6 +103,86 @@ var _ = Describe("Pipelines", func() {
 			mockADOClient.AssertNumberOfCalls(t, "GetRun", 3)
 			mockADOClient.AssertExpectations(GinkgoT())
 		})
+
+		It("should stop waiting when context is cancelled", func() {
+			cancelledCtx, cancel := context.WithCancel(ctx)
+			cancel()
+
+			_, err := pipelines.GetRunResult(cancelledCtx, mockADOClient, adoConfig, ptr.To(42))
+
+			Expect(err).To(MatchError(context.Canceled))
+			mockADOClient.AssertNotCalled(t, "GetRun")
+		})
+	})
+
+	Describe("GetRun", func() {
+		var runArgs adoPipelines.GetRunArgs
+
+		BeforeEach(func() {
+			runArgs = adoPipelines.GetRunArgs{
+				Project:    &adoConfig.ADOProjectName,
+				PipelineId: &adoConfig.ADOPipelineID,
+				RunId:      ptr.To(42),
+			}
+		})
+
+		It("should return the pipeline run without waiting for completion", func() {
+			mockRunInProgress := &adoPipelines.Run{State: &adoPipelines.RunStateValues.InProgress}
+			mockADOClient.On("GetRun", ctx, runArgs).Return(mockRunInProgress, nil)
+
+			run, err := pipelines.GetRun(ctx, mockADOClient, adoConfig, ptr.To(42))
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(run).To(Equal(mockRunInProgress))
+			mockADOClient.AssertNumberOfCalls(t, "GetRun", 1)
+			mockADOClient.AssertExpectations(GinkgoT())
+		})
+
+		It("should handle ADO client error", func() {
+			mockADOClient.On("GetRun", ctx, runArgs).Return(nil, fmt.Errorf("ADO client error"))
+
+			_, err := pipelines.GetRun(ctx, mockADOClient, adoConfig, ptr.To(42))
+
+			Expect(err).To(HaveOccurred())
+			mockADOClient.AssertNumberOfCalls(t, "GetRun", 3)
+			mockADOClient.AssertExpectations(GinkgoT())
+		})
+	})
+
+	Describe("CancelRun", func() {
+		var (
+			mockBuildClient *pipelinesMocks.MockBuildClient
+			updateArgs      build.UpdateBuildArgs
+		)
+
+		BeforeEach(func() {
+			mockBuildClient = pipelinesMocks.NewMockBuildClient(t)
+			updateArgs = build.UpdateBuildArgs{
+				Build:   &build.Build{Status: &build.BuildStatusValues.Cancelling},
+				Project: &adoConfig.ADOProjectName,
+				BuildId: ptr.To(42),
+			}
+		})
+
+		It("should request cancellation of the pipeline run", func() {
+			mockBuildClient.On("UpdateBuild", ctx, updateArgs).Return(&build.Build{Status: &build.BuildStatusValues.Cancelling}, nil)
+
+			err := pipelines.CancelRun(ctx, mockBuildClient, adoConfig, ptr.To(42))
+
+			Expect(err).ToNot(HaveOccurred())
+			mockBuildClient.AssertNumberOfCalls(t, "UpdateBuild", 1)
+			mockBuildClient.AssertExpectations(GinkgoT())
+		})
+
+		It("should handle ADO client error", func() {
+			mockBuildClient.On("UpdateBuild", ctx, updateArgs).Return(nil, fmt.Errorf("ADO client error"))
+
+			err := pipelines.CancelRun(ctx, mockBuildClient, adoConfig, ptr.To(42))
+
+			Expect(err).To(HaveOccurred())
+			mockBuildClient.AssertNumberOfCalls(t, "UpdateBuild", 3)
+			mockBuildClient.AssertExpectations(GinkgoT())
+		})
 	})
 
 	Describe("NewRunPipelineArgs", func() {
@@ -0,0 +1,183 @@
+package pipelines
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/avast/retry-go/v5"
+	"github.com/google/uuid"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/build"
+)
+
+// RunLogsIndexFile is the name of the index file written together with the ADO pipeline run logs.
+const RunLogsIndexFile = "index.json"
+
+// timelineRecordTypePhase is the type of timeline records grouping jobs.
+// Phases are internal to ADO and usually have generated names, so they are not part of step paths.
+const timelineRecordTypePhase = "Phase"
+
+// fileNameUnsafeChars matches characters not allowed in names of log files.
+var fileNameUnsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// RunLogsIndex maps steps of the ADO pipeline run to files with their logs.
+type RunLogsIndex struct {
+	// RunID is the ID of the ADO pipeline run
+	RunID int `json:"run_id"`
+	// Steps is a list of timeline records with logs, sorted by log ID
+	Steps []StepLog `json:"steps"`
+}
+
+// StepLog describes a log file of a single timeline record of the ADO pipeline run.
+type StepLog struct {
+	// Name of the timeline record
+	Name string `json:"name"`
+	// Type of the timeline record, e.g. Stage, Job or Task
+	Type string `json:"type"`
+	// Path of the timeline record in the run, e.g. stage/job/task
+	Path string `json:"path"`
+	// LogID is the ID of the ADO log
+	LogID int `json:"log_id"`
+	// File is the name of the log file relative to the logs directory
+	File string `json:"file"`
+	// State of the timeline record
+	State string `json:"state,omitempty"`
+	// Result of the timeline record
+	Result string `json:"result,omitempty"`
+}
+
+// DownloadRunLogs downloads logs of all timeline records of the ADO pipeline run into the directory.
+// Log files are named after the path of the timeline record, e.g. stage/job/task, prefixed with the log ID.
+// The index mapping records to files and results is written to the RunLogsIndexFile in the same directory.
+// Requests are retried according to the retry strategy from the ADO configuration.
+func DownloadRunLogs(ctx context.Context, buildClient BuildClient, adoConfig Config, pipelineRunID *int, dir string) (*RunLogsIndex, error) {
+	timeline, err := retry.NewWithData[*build.Timeline](
+		retry.Attempts(adoConfig.ADORetryStrategy.Attempts),
+		retry.Delay(adoConfig.ADORetryStrategy.Delay),
+		retry.Context(ctx),
+	).Do(
+		func() (*build.Timeline, error) {
+			return buildClient.GetBuildTimeline(ctx, build.GetBuildTimelineArgs{
+				Project: &adoConfig.ADOProjectName,
+				BuildId: pipelineRunID,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed getting ADO pipeline run timeline, err: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed creating logs directory, err: %w", err)
+	}
+
+	index := &RunLogsIndex{RunID: *pipelineRunID, Steps: []StepLog{}}
+	if timeline != nil && timeline.Records != nil {
+		for _, step := range recordsWithLogs(*timeline.Records) {
+			lines, err := retry.NewWithData[*[]string](
+				retry.Attempts(adoConfig.ADORetryStrategy.Attempts),
+				retry.Delay(adoConfig.ADORetryStrategy.Delay),
+				retry.Context(ctx),
+			).Do(
+				func() (*[]string, error) {
+					return buildClient.GetBuildLogLines(ctx, build.GetBuildLogLinesArgs{
+						Project: &adoConfig.ADOProjectName,
+						BuildId: pipelineRunID,
+						LogId:   &step.LogID,
+					})
+				},
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed getting log lines of %s, err: %w", step.Path, err)
+			}
+
+			var content string
+			if lines != nil && len(*lines) > 0 {
+				content = strings.Join(*lines, "\n") + "\n"
+			}
+			if err := os.WriteFile(filepath.Join(dir, step.File), []byte(content), 0644); err != nil {
+				return nil, fmt.Errorf("failed writing log file of %s, err: %w", step.Path, err)
+			}
+			index.Steps = append(index.Steps, step)
+		}
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed marshalling logs index, err: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, RunLogsIndexFile), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed writing logs index, err: %w", err)
+	}
+	return index, nil
+}
+
+// recordsWithLogs returns descriptions of log files of timeline records which have a log, sorted by log ID.
+func recordsWithLogs(records []build.TimelineRecord) []StepLog {
+	byID := make(map[uuid.UUID]build.TimelineRecord, len(records))
+	for _, record := range records {
+		if record.Id != nil {
+			byID[*record.Id] = record
+		}
+	}
+
+	var steps []StepLog
+	for _, record := range records {
+		if record.Log == nil || record.Log.Id == nil {
+			continue
+		}
+		path := recordPath(record, byID)
+		step := StepLog{
+			Name:  stepName(record),
+			Path:  strings.Join(path, "/"),
+			LogID: *record.Log.Id,
+			File:  logFileName(*record.Log.Id, path),
+		}
+		if record.Type != nil {
+			step.Type = *record.Type
+		}
+		if record.State != nil {
+			step.State = string(*record.State)
+		}
+		if record.Result != nil {
+			step.Result = string(*record.Result)
+		}
+		steps = append(steps, step)
+	}
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].LogID < steps[j].LogID
+	})
+	return steps
+}
+
+// recordPath returns names of the timeline record and its ancestors, starting from the top level record.
+// Phase records are skipped.
+func recordPath(record build.TimelineRecord, byID map[uuid.UUID]build.TimelineRecord) []string {
+	path := []string{stepName(record)}
+	// Limit the depth, so malformed timelines with cycles don't loop forever.
+	for depth := 0; record.ParentId != nil && depth < len(byID); depth++ {
+		parent, ok := byID[*record.ParentId]
+		if !ok {
+			break
+		}
+		if parent.Type == nil || *parent.Type != timelineRecordTypePhase {
+			path = append([]string{stepName(parent)}, path...)
+		}
+		record = parent
+	}
+	return path
+}
+
+// logFileName returns the name of the log file for the timeline record path.
+func logFileName(logID int, path []string) string {
+	segments := make([]string, 0, len(path))
+	for _, name := range path {
+		segments = append(segments, strings.Trim(fileNameUnsafeChars.ReplaceAllString(name, "-"), "-"))
+	}
+	return fmt.Sprintf("%03d_%s.log", logID, strings.Join(segments, "_"))
+}
@@ -0,0 +1,103 @@
+package pipelines_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/kyma-project/test-infra/pkg/azuredevops/pipelines"
+	pipelinesMocks "github.com/kyma-project/test-infra/pkg/azuredevops/pipelines/mocks"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/build"
+	"k8s.io/utils/ptr"
+)
+
+var _ = Describe("DownloadRunLogs", func() {
+	var (
+		ctx             context.Context
+		mockBuildClient *pipelinesMocks.MockBuildClient
+		adoConfig       pipelines.Config
+		timelineArgs    build.GetBuildTimelineArgs
+		dir             string
+		t               ginkgoT
+	)
+
+	logLinesArgs := func(logID int) build.GetBuildLogLinesArgs {
+		return build.GetBuildLogLinesArgs{
+			Project: &adoConfig.ADOProjectName,
+			BuildId: ptr.To(42),
+			LogId:   ptr.To(logID),
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		t = ginkgoT{}
+		t.GinkgoTInterface = GinkgoT()
+		mockBuildClient = pipelinesMocks.NewMockBuildClient(t)
+		adoConfig = pipelines.Config{
+			ADOProjectName: "example-project",
+			ADORetryStrategy: pipelines.RetryStrategy{
+				Attempts: 2,
+				Delay:    time.Millisecond,
+			},
+		}
+		timelineArgs = build.GetBuildTimelineArgs{
+			Project: &adoConfig.ADOProjectName,
+			BuildId: ptr.To(42),
+		}
+		dir = filepath.Join(GinkgoT().TempDir(), "artifacts")
+	})
+
+	It("should write logs of all timeline records and the index", func() {
+		stageID, phaseID, jobID, taskID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+		timeline := &build.Timeline{Records: &[]build.TimelineRecord{
+			{Id: &taskID, ParentId: &jobID, Name: ptr.To("Build image"), Type: ptr.To("Task"), Log: &build.BuildLogReference{Id: ptr.To(5)},
+				State: &build.TimelineRecordStateValues.Completed, Result: &build.TaskResultValues.Succeeded},
+			{Id: &jobID, ParentId: &phaseID, Name: ptr.To("Build job"), Type: ptr.To("Job"), Log: &build.BuildLogReference{Id: ptr.To(7)},
+				State: &build.TimelineRecordStateValues.Completed, Result: &build.TaskResultValues.Failed},
+			{Id: &phaseID, ParentId: &stageID, Name: ptr.To("__default"), Type: ptr.To("Phase")},
+			{Id: &stageID, Name: ptr.To("Build"), Type: ptr.To("Stage")},
+		}}
+		mockBuildClient.On("GetBuildTimeline", ctx, timelineArgs).Return(timeline, nil)
+		mockBuildClient.On("GetBuildLogLines", ctx, logLinesArgs(5)).Return(&[]string{"line 1", "line 2"}, nil)
+		mockBuildClient.On("GetBuildLogLines", ctx, logLinesArgs(7)).Return(&[]string{"job log"}, nil)
+
+		index, err := pipelines.DownloadRunLogs(ctx, mockBuildClient, adoConfig, ptr.To(42), dir)
+
+		Expect(err).ToNot(HaveOccurred())
+		expectedIndex := &pipelines.RunLogsIndex{RunID: 42, Steps: []pipelines.StepLog{
+			{Name: "Build image", Type: "Task", Path: "Build/Build job/Build image", LogID: 5, File: "005_Build_Build-job_Build-image.log", State: "completed", Result: "succeeded"},
+			{Name: "Build job", Type: "Job", Path: "Build/Build job", LogID: 7, File: "007_Build_Build-job.log", State: "completed", Result: "failed"},
+		}}
+		Expect(index).To(Equal(expectedIndex))
+
+		Expect(os.ReadFile(filepath.Join(dir, "005_Build_Build-job_Build-image.log"))).To(Equal([]byte("line 1\nline 2\n")))
+		Expect(os.ReadFile(filepath.Join(dir, "007_Build_Build-job.log"))).To(Equal([]byte("job log\n")))
+
+		data, err := os.ReadFile(filepath.Join(dir, pipelines.RunLogsIndexFile))
+		Expect(err).ToNot(HaveOccurred())
+		var writtenIndex pipelines.RunLogsIndex
+		Expect(json.Unmarshal(data, &writtenIndex)).To(Succeed())
+		Expect(&writtenIndex).To(Equal(expectedIndex))
+		mockBuildClient.AssertExpectations(GinkgoT())
+	})
+
+	It("should handle ADO client error", func() {
+		mockBuildClient.On("GetBuildTimeline", ctx, timelineArgs).Return(nil, fmt.Errorf("ADO client error"))
+
+		_, err := pipelines.DownloadRunLogs(ctx, mockBuildClient, adoConfig, ptr.To(42), dir)
+
+		Expect(err).To(HaveOccurred())
+		Expect(dir).ToNot(BeADirectory())
+		mockBuildClient.AssertNumberOfCalls(t, "GetBuildTimeline", 2)
+		mockBuildClient.AssertExpectations(GinkgoT())
+	})
+})
@@ -172,6 +172,44 @@ func (p OCIImageBuilderTemplateParams) SetUseRestrictedRegistry() {
 	p["useRestrictedRegistry"] = "true"
 }
 
+// SetRegistries sets optional parameter Registries.
+// This parameter is used to set registries the image is pushed to.
+// Multiple registries can be specified as a comma-separated list.
+func (p OCIImageBuilderTemplateParams) SetRegistries(registries string) {
+	p["Registries"] = registries
+}
+
+// SetCache sets optional parameters Cache and CacheRepo.
+// These parameters are used to enable docker buildx cache stored in the cacheRepo repository.
+func (p OCIImageBuilderTemplateParams) SetCache(cacheRepo string) {
+	p["Cache"] = "true"
+	p["CacheRepo"] = cacheRepo
+}
+
+// SetCacheRunLayers sets optional parameter CacheRunLayers.
+// This parameter is used to cache layers created by RUN instructions.
+func (p OCIImageBuilderTemplateParams) SetCacheRunLayers() {
+	p["CacheRunLayers"] = "true"
+}
+
+// SetCacheCopyLayers sets optional parameter CacheCopyLayers.
+// This parameter is used to cache layers created by COPY instructions.
+func (p OCIImageBuilderTemplateParams) SetCacheCopyLayers() {
+	p["CacheCopyLayers"] = "true"
+}
+
+// SetReproducible sets optional parameter Reproducible.
+// This parameter is used to strip timestamps out of the built image and make it reproducible.
+func (p OCIImageBuilderTemplateParams) SetReproducible() {
+	p["Reproducible"] = "true"
+}
+
+// SetLogFormat sets optional parameter LogFormat.
+// This parameter is used to set the format of docker buildx logs, for example, "color", "text" or "json".
+func (p OCIImageBuilderTemplateParams) SetLogFormat(format string) {
+	p["LogFormat"] = format
+}
+
 // Validate validates if required OCIImageBuilderTemplateParams are set.
 // Returns ErrRequiredParamNotSet error if any required parameter is not set.
 func (p OCIImageBuilderTemplateParams) Validate() error {
@@ -112,6 +112,39 @@ var _ = Describe("Test OCIImageBuilderTemplateParams", func() {
 		Expect(params["useRestrictedRegistry"]).To(Equal("true"))
 	})
 
+	It("sets the correct Registries", func() {
+		params.SetRegistries("europe-docker.pkg.dev/kyma-project/dev,europe-docker.pkg.dev/kyma-project/dev-mirror")
+		Expect(params["Registries"]).To(Equal("europe-docker.pkg.dev/kyma-project/dev,europe-docker.pkg.dev/kyma-project/dev-mirror"))
+	})
+
+	It("sets the correct Cache parameters", func() {
+		params.SetCache("europe-docker.pkg.dev/kyma-project/cache")
+		Expect(params["Cache"]).To(Equal("true"))
+		Expect(params["CacheRepo"]).To(Equal("europe-docker.pkg.dev/kyma-project/cache"))
+		Expect(params).NotTo(HaveKey("CacheRunLayers"))
+		Expect(params).NotTo(HaveKey("CacheCopyLayers"))
+	})
+
+	It("sets the correct CacheRunLayers", func() {
+		params.SetCacheRunLayers()
+		Expect(params["CacheRunLayers"]).To(Equal("true"))
+	})
+
+	It("sets the correct CacheCopyLayers", func() {
+		params.SetCacheCopyLayers()
+		Expect(params["CacheCopyLayers"]).To(Equal("true"))
+	})
+
+	It("sets the correct Reproducible", func() {
+		params.SetReproducible()
+		Expect(params["Reproducible"]).To(Equal("true"))
+	})
+
+	It("sets the correct LogFormat", func() {
+		params.SetLogFormat("json")
+		Expect(params["LogFormat"]).To(Equal("json"))
+	})
+
 	// TODO: Improve assertions with more specific matchers and values.
 	It("validates the params correctly", func() {
 		// Set all required parameters
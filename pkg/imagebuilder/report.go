@@ -33,6 +33,8 @@ type BuildReport struct {
 	RegistryURL string `json:"repository_path"`
 	// Architectures is the architecture of the image
 	Architectures []string `json:"architectures"`
+	// Registries is a list of registries chosen for the image, the dev registry for pull requests and merge groups
+	Registries []string `json:"registries,omitempty"`
 }
 
 func NewBuildReportFromLogs(log string) (*BuildReport, error) {
@@ -67,3 +69,77 @@ func WriteReportToFile(report *BuildReport, path string) error {
 
 	return nil
 }
+
+// CombinedBuildReport aggregates build results of multiple images built in a single run.
+type CombinedBuildReport struct {
+	// Status is the overall status of all builds, it's Succeeded only if all builds succeeded
+	Status string `json:"status"`
+	// Builds is a list of results for each built image, in the order images were requested
+	Builds []ImageBuildResult `json:"builds"`
+}
+
+// ImageBuildResult holds the result of a single image build within a combined run.
+type ImageBuildResult struct {
+	// Name is the name of the image
+	Name string `json:"image_name"`
+	// Status is the status of the image build
+	Status string `json:"status"`
+	// Report is the build report of the image, it's empty if the build didn't produce a report
+	Report *BuildReport `json:"report,omitempty"`
+	// Error is the error message if the build failed before producing a report
+	Error string `json:"error,omitempty"`
+}
+
+// NewCombinedBuildReport creates a combined report from the given results.
+// The overall status is Succeeded only if every build succeeded, otherwise it's Failed.
+func NewCombinedBuildReport(builds []ImageBuildResult) *CombinedBuildReport {
+	status := "Succeeded"
+	for _, b := range builds {
+		if b.Status != "Succeeded" {
+			status = "Failed"
+			break
+		}
+	}
+	return &CombinedBuildReport{
+		Status: status,
+		Builds: builds,
+	}
+}
+
+// Images returns a list of all images built in the combined run.
+func (r *CombinedBuildReport) Images() []string {
+	var images []string
+	for _, b := range r.Builds {
+		if b.Report != nil {
+			images = append(images, b.Report.Images...)
+		}
+	}
+	return images
+}
+
+// Digests returns a mapping of image names to their digests.
+// Images without a report are skipped.
+func (r *CombinedBuildReport) Digests() map[string]string {
+	digests := make(map[string]string)
+	for _, b := range r.Builds {
+		if b.Report != nil {
+			digests[b.Name] = b.Report.Digest
+		}
+	}
+	return digests
+}
+
+// WriteCombinedReportToFile writes the combined report as JSON to the file.
+func WriteCombinedReportToFile(report *CombinedBuildReport, path string) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal combined report: %w", err)
+	}
+
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write combined report to file: %w", err)
+	}
+
+	return nil
+}
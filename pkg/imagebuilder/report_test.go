@@ -2,6 +2,8 @@ package imagebuilder
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -175,4 +177,46 @@ var _ = Describe("Report", func() {
 			Expect(path).To(BeAnExistingFile())
 		})
 	})
+
+	Describe("CombinedBuildReport", func() {
+		succeeded := ImageBuildResult{
+			Name:   "image-a",
+			Status: "Succeeded",
+			Report: &BuildReport{
+				Status: "Succeeded",
+				Name:   "image-a",
+				Images: []string{"europe-docker.pkg.dev/kyma-project/prod/image-a:v1"},
+				Digest: "sha256:aaa",
+			},
+		}
+		failed := ImageBuildResult{
+			Name:   "image-b",
+			Status: "Failed",
+			Error:  "failed triggering pipeline",
+		}
+
+		It("has succeeded status when all builds succeeded", func() {
+			report := NewCombinedBuildReport([]ImageBuildResult{succeeded})
+			Expect(report.Status).To(Equal("Succeeded"))
+		})
+
+		It("has failed status and keeps other results when any build failed", func() {
+			report := NewCombinedBuildReport([]ImageBuildResult{succeeded, failed})
+			Expect(report.Status).To(Equal("Failed"))
+			Expect(report.Builds).To(HaveLen(2))
+			Expect(report.Images()).To(Equal([]string{"europe-docker.pkg.dev/kyma-project/prod/image-a:v1"}))
+			Expect(report.Digests()).To(Equal(map[string]string{"image-a": "sha256:aaa"}))
+		})
+
+		It("writes the combined report to a file", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "combined-report.json")
+			err := WriteCombinedReportToFile(NewCombinedBuildReport([]ImageBuildResult{succeeded, failed}), path)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(path).To(BeAnExistingFile())
+			info, err := os.Stat(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm() &^ 0644).To(BeZero())
+		})
+	})
 })
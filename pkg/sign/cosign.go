@@ -0,0 +1,478 @@
+package sign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	errutil "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// Enum of places where the cosign signer stores signatures.
+const (
+	// CosignSignatureModeTag stores signatures in the sha256-<digest>.sig tag of the signed repository, the default cosign scheme.
+	CosignSignatureModeTag = "tag"
+	// CosignSignatureModeReferrers stores signatures as OCI artifacts referring to the signed image, see the OCI 1.1 referrers API.
+	CosignSignatureModeReferrers = "referrers"
+)
+
+const (
+	// CosignSimpleSigningMediaType is the media type of the layer holding the simple signing payload.
+	CosignSimpleSigningMediaType types.MediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	// CosignSignatureArtifactType is the artifact type of signatures stored with the referrers mode.
+	CosignSignatureArtifactType types.MediaType = "application/vnd.dev.cosign.artifact.sig.v1+json"
+	// CosignSignatureAnnotation is the annotation of the payload layer holding the base64 encoded signature.
+	CosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	// cosignSignatureType is the type of the simple signing payload of container images.
+	cosignSignatureType = "cosign container image signature"
+)
+
+func init() {
+	RegisterBackend(TypeCosignBackend, decodeCosignConfig)
+}
+
+// decodeCosignConfig decodes the config of the cosign signer.
+func decodeCosignConfig(decode func(v any) error) (SignerFactory, error) {
+	var cc CosignConfig
+	if err := decode(&cc); err != nil {
+		return nil, err
+	}
+	return &cc, nil
+}
+
+// CosignConfig holds the configuration for the CosignSigner.
+// The private key is read from the file or the environment variable.
+// The key must be an unencrypted PEM encoded ECDSA or ED25519 key, in the PKCS #8 or SEC 1 format.
+type CosignConfig struct {
+	// KeyPath is a path to the private key file
+	KeyPath string `yaml:"key-path,omitempty" json:"key-path,omitempty"`
+	// KeyEnv is the environment variable holding the private key, used when KeyPath is empty
+	KeyEnv string `yaml:"key-env,omitempty" json:"key-env,omitempty"`
+	// SignatureMode is the place where signatures are stored, tag (default) or referrers
+	SignatureMode string `yaml:"signature-mode,omitempty" json:"signature-mode,omitempty"`
+	// PublicKeyPath is a path to the PEM encoded public key used to verify signatures.
+	// If it's empty, the public key is derived from the private key.
+	PublicKeyPath string `yaml:"public-key-path,omitempty" json:"public-key-path,omitempty"`
+}
+
+// signatureMode returns the configured signature mode, or an error if it's not supported.
+func (cc *CosignConfig) signatureMode() (string, error) {
+	mode := cc.SignatureMode
+	if mode == "" {
+		mode = CosignSignatureModeTag
+	}
+	if mode != CosignSignatureModeTag && mode != CosignSignatureModeReferrers {
+		return "", fmt.Errorf("unsupported signature mode %s, supported modes: %s, %s", mode, CosignSignatureModeTag, CosignSignatureModeReferrers)
+	}
+	return mode, nil
+}
+
+// privateKey reads the private key from the file or the environment variable.
+func (cc *CosignConfig) privateKey() (crypto.Signer, error) {
+	var keyPEM []byte
+	switch {
+	case cc.KeyPath != "":
+		data, err := os.ReadFile(cc.KeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+		keyPEM = data
+	case cc.KeyEnv != "":
+		keyPEM = []byte(os.Getenv(cc.KeyEnv))
+		if len(keyPEM) == 0 {
+			return nil, fmt.Errorf("environment variable %s with the key is not set", cc.KeyEnv)
+		}
+	default:
+		return nil, fmt.Errorf("key-path or key-env must be set")
+	}
+	return ParseCosignPrivateKey(keyPEM)
+}
+
+// NewSigner constructs a new CosignSigner with the key from the config.
+func (cc *CosignConfig) NewSigner() (Signer, error) {
+	mode, err := cc.signatureMode()
+	if err != nil {
+		return nil, err
+	}
+	key, err := cc.privateKey()
+	if err != nil {
+		return nil, err
+	}
+	return &CosignSigner{
+		key:           key,
+		mode:          mode,
+		remoteOptions: getRemoteOptions(),
+	}, nil
+}
+
+// NewVerifier constructs a new CosignVerifier with the public key from the config.
+// Signatures are looked up in the same place as the signer with the config pushes them.
+func (cc *CosignConfig) NewVerifier() (Verifier, error) {
+	mode, err := cc.signatureMode()
+	if err != nil {
+		return nil, err
+	}
+	var pub crypto.PublicKey
+	if cc.PublicKeyPath != "" {
+		data, err := os.ReadFile(cc.PublicKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key file: %w", err)
+		}
+		pub, err = ParseCosignPublicKey(data)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		key, err := cc.privateKey()
+		if err != nil {
+			return nil, fmt.Errorf("public-key-path is not set, failed to derive the public key: %w", err)
+		}
+		pub = key.Public()
+	}
+	return &CosignVerifier{
+		publicKey:     pub,
+		mode:          mode,
+		remoteOptions: getRemoteOptions(),
+	}, nil
+}
+
+// ParseCosignPublicKey parses the PEM encoded ECDSA or ED25519 public key, e.g. cosign.pub generated by cosign.
+func ParseCosignPublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("failed to decode PEM public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		return k, nil
+	case ed25519.PublicKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T, only ECDSA and ED25519 keys are supported", key)
+	}
+}
+
+// ParseCosignPrivateKey parses the PEM encoded ECDSA or ED25519 private key.
+// Encrypted keys generated with cosign generate-key-pair are not supported,
+// export the key without a password, e.g. with openssl pkcs8 -topk8 -nocrypt.
+func ParseCosignPrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM private key")
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse PKCS #8 private key: %w", err)
+		}
+		switch k := key.(type) {
+		case *ecdsa.PrivateKey:
+			return k, nil
+		case ed25519.PrivateKey:
+			return k, nil
+		default:
+			return nil, fmt.Errorf("unsupported private key type %T, only ECDSA and ED25519 keys are supported", key)
+		}
+	case "EC PRIVATE KEY":
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse EC private key: %w", err)
+		}
+		return key, nil
+	case "ENCRYPTED SIGSTORE PRIVATE KEY", "ENCRYPTED COSIGN PRIVATE KEY":
+		return nil, fmt.Errorf("encrypted cosign private keys are not supported, provide an unencrypted PKCS #8 key")
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %s", block.Type)
+	}
+}
+
+// SimpleSigning is the payload signed by cosign, see https://github.com/containers/image/blob/main/docs/containers-signature.5.md
+type SimpleSigning struct {
+	Critical SimpleSigningCritical `json:"critical"`
+	Optional map[string]any        `json:"optional"`
+}
+
+// SimpleSigningCritical holds the signed identity and digest of the image.
+type SimpleSigningCritical struct {
+	Identity struct {
+		DockerReference string `json:"docker-reference"`
+	} `json:"identity"`
+	Image struct {
+		DockerManifestDigest string `json:"docker-manifest-digest"`
+	} `json:"image"`
+	Type string `json:"type"`
+}
+
+// CosignSigner signs image digests with the private key and pushes cosign signatures to the registry of the image.
+// It doesn't use the Fulcio certificate authority or the Rekor transparency log, so it works offline.
+type CosignSigner struct {
+	key           crypto.Signer
+	mode          string
+	remoteOptions []remote.Option
+}
+
+// Sign signs the digest of each image. Images referenced by tag are resolved to the digest first.
+// A multi-arch image is signed by the digest of its index.
+func (cs *CosignSigner) Sign(images []string) error {
+	var errs []error
+	for _, image := range images {
+		if err := cs.signImage(image); err != nil {
+			errs = append(errs, fmt.Errorf("failed to sign image %s: %w", image, err))
+		}
+	}
+	return errutil.NewAggregate(errs)
+}
+
+// signImage signs the image and pushes the signature.
+func (cs *CosignSigner) signImage(image string) error {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return fmt.Errorf("failed to parse image reference: %w", err)
+	}
+	desc, err := remote.Head(ref, cs.remoteOptions...)
+	if err != nil {
+		return fmt.Errorf("failed to fetch descriptor: %w", err)
+	}
+	if d, ok := ref.(name.Digest); ok && d.DigestStr() != desc.Digest.String() {
+		return fmt.Errorf("registry returned digest %s, expected %s", desc.Digest, d.DigestStr())
+	}
+
+	payload, err := SimpleSigningPayload(ref.Context().Name(), desc.Digest)
+	if err != nil {
+		return err
+	}
+	signature, err := cs.signPayload(payload)
+	if err != nil {
+		return fmt.Errorf("failed to sign payload: %w", err)
+	}
+
+	layer := static.NewLayer(payload, CosignSimpleSigningMediaType)
+	annotations := map[string]string{CosignSignatureAnnotation: base64.StdEncoding.EncodeToString(signature)}
+	if cs.mode == CosignSignatureModeReferrers {
+		return cs.pushReferrer(ref.Context(), *desc, layer, annotations)
+	}
+	return cs.pushSignatureTag(ref.Context(), desc.Digest, layer, annotations)
+}
+
+// SimpleSigningPayload returns the simple signing payload of the image digest in the repository.
+func SimpleSigningPayload(repository string, digest v1.Hash) ([]byte, error) {
+	var s SimpleSigning
+	s.Critical.Identity.DockerReference = repository
+	s.Critical.Image.DockerManifestDigest = digest.String()
+	s.Critical.Type = cosignSignatureType
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal simple signing payload: %w", err)
+	}
+	return payload, nil
+}
+
+// signPayload signs the payload the same way as cosign does.
+// ECDSA keys sign the SHA-256 hash of the payload, ED25519 keys sign the payload itself.
+func (cs *CosignSigner) signPayload(payload []byte) ([]byte, error) {
+	if _, ok := cs.key.(ed25519.PrivateKey); ok {
+		return cs.key.Sign(rand.Reader, payload, crypto.Hash(0))
+	}
+	digest := sha256.Sum256(payload)
+	return cs.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+// pushSignatureTag appends the signature layer to the signature image in the sha256-<digest>.sig tag.
+// Signatures pushed before are kept, so the image can be signed with several keys.
+func (cs *CosignSigner) pushSignatureTag(repo name.Repository, digest v1.Hash, layer v1.Layer, annotations map[string]string) error {
+	tag := repo.Tag(fmt.Sprintf("%s-%s.sig", digest.Algorithm, digest.Hex))
+	base, err := remote.Image(tag, cs.remoteOptions...)
+	if err != nil {
+		var terr *transport.Error
+		if !errors.As(err, &terr) || terr.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("failed to fetch signature image %s: %w", tag, err)
+		}
+		base = mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), types.OCIConfigJSON)
+	}
+	sigImage, err := mutate.Append(base, mutate.Addendum{Layer: layer, Annotations: annotations})
+	if err != nil {
+		return fmt.Errorf("failed to append signature layer: %w", err)
+	}
+	if err := remote.Write(tag, sigImage, cs.remoteOptions...); err != nil {
+		return fmt.Errorf("failed to push signature image %s: %w", tag, err)
+	}
+	return nil
+}
+
+// pushReferrer pushes the signature as the OCI artifact with the signed image as its subject.
+// Registries without the referrers API get the referrers tag schema fallback.
+func (cs *CosignSigner) pushReferrer(repo name.Repository, subject v1.Descriptor, layer v1.Layer, annotations map[string]string) error {
+	base := mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), CosignSignatureArtifactType)
+	sigImage, err := mutate.Append(base, mutate.Addendum{Layer: layer, Annotations: annotations})
+	if err != nil {
+		return fmt.Errorf("failed to append signature layer: %w", err)
+	}
+	sigImage = mutate.Subject(sigImage, v1.Descriptor{
+		MediaType: subject.MediaType,
+		Digest:    subject.Digest,
+		Size:      subject.Size,
+	}).(v1.Image)
+	digest, err := sigImage.Digest()
+	if err != nil {
+		return fmt.Errorf("failed to compute signature digest: %w", err)
+	}
+	if err := remote.Write(repo.Digest(digest.String()), sigImage, cs.remoteOptions...); err != nil {
+		return fmt.Errorf("failed to push signature artifact: %w", err)
+	}
+	return nil
+}
+
+// CosignVerifier checks that image digests are signed with the private key matching the public key.
+type CosignVerifier struct {
+	publicKey     crypto.PublicKey
+	mode          string
+	remoteOptions []remote.Option
+}
+
+// Verify returns nil if any signature of the image digest verifies with the public key.
+// Images referenced by tag are resolved to the digest first.
+func (cv *CosignVerifier) Verify(image string) error {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return fmt.Errorf("failed to parse image reference: %w", err)
+	}
+	desc, err := remote.Head(ref, cv.remoteOptions...)
+	if err != nil {
+		return fmt.Errorf("failed to fetch descriptor: %w", err)
+	}
+	if d, ok := ref.(name.Digest); ok && d.DigestStr() != desc.Digest.String() {
+		return fmt.Errorf("registry returned digest %s, expected %s", desc.Digest, d.DigestStr())
+	}
+
+	sigImages, err := cv.signatureImages(ref.Context(), desc.Digest)
+	if err != nil {
+		return err
+	}
+	for _, sigImage := range sigImages {
+		verified, err := cv.verifySignatureImage(sigImage, ref.Context(), desc.Digest)
+		if err != nil {
+			return err
+		}
+		if verified {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no cosign signature of %s verifies with the public key", ErrSignatureNotFound, desc.Digest)
+}
+
+// signatureImages returns images holding signatures of the digest, stored in the place used by the signature mode.
+func (cv *CosignVerifier) signatureImages(repo name.Repository, digest v1.Hash) ([]v1.Image, error) {
+	if cv.mode == CosignSignatureModeReferrers {
+		idx, err := remote.Referrers(repo.Digest(digest.String()), cv.remoteOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch referrers: %w", err)
+		}
+		manifest, err := idx.IndexManifest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read referrers: %w", err)
+		}
+		var images []v1.Image
+		for _, m := range manifest.Manifests {
+			if m.ArtifactType != string(CosignSignatureArtifactType) {
+				continue
+			}
+			img, err := remote.Image(repo.Digest(m.Digest.String()), cv.remoteOptions...)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch signature artifact %s: %w", m.Digest, err)
+			}
+			images = append(images, img)
+		}
+		return images, nil
+	}
+
+	tag := repo.Tag(fmt.Sprintf("%s-%s.sig", digest.Algorithm, digest.Hex))
+	img, err := remote.Image(tag, cv.remoteOptions...)
+	if err != nil {
+		var terr *transport.Error
+		if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to fetch signature image %s: %w", tag, err)
+	}
+	return []v1.Image{img}, nil
+}
+
+// verifySignatureImage returns true if any signature layer of the image holds the payload of the digest in the repository
+// and its signature verifies with the public key.
+// A signature of the same digest made for another repository doesn't verify, even if it was copied to the repository.
+func (cv *CosignVerifier) verifySignatureImage(sigImage v1.Image, repo name.Repository, digest v1.Hash) (bool, error) {
+	manifest, err := sigImage.Manifest()
+	if err != nil {
+		return false, fmt.Errorf("failed to read signature manifest: %w", err)
+	}
+	for _, layerDesc := range manifest.Layers {
+		if layerDesc.MediaType != CosignSimpleSigningMediaType {
+			continue
+		}
+		signature, err := base64.StdEncoding.DecodeString(layerDesc.Annotations[CosignSignatureAnnotation])
+		if err != nil {
+			continue
+		}
+		layer, err := sigImage.LayerByDigest(layerDesc.Digest)
+		if err != nil {
+			return false, fmt.Errorf("failed to read signature layer: %w", err)
+		}
+		rc, err := layer.Uncompressed()
+		if err != nil {
+			return false, fmt.Errorf("failed to read signature payload: %w", err)
+		}
+		payload, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return false, fmt.Errorf("failed to read signature payload: %w", err)
+		}
+		var s SimpleSigning
+		if err := json.Unmarshal(payload, &s); err != nil || s.Critical.Image.DockerManifestDigest != digest.String() {
+			continue
+		}
+		if s.Critical.Identity.DockerReference != repo.Name() {
+			continue
+		}
+		if verifyPayload(cv.publicKey, payload, signature) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// verifyPayload checks the signature of the payload the same way as cosign does, see CosignSigner.signPayload.
+func verifyPayload(pub crypto.PublicKey, payload, signature []byte) bool {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(payload)
+		return ecdsa.VerifyASN1(k, digest[:], signature)
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, payload, signature)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,434 @@
+package sign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// pkcs8PEM returns the PEM encoded PKCS #8 private key.
+func pkcs8PEM(t *testing.T, key crypto.Signer) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal private key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func newECDSAKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ECDSA key: %v", err)
+	}
+	return key
+}
+
+func newED25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ED25519 key: %v", err)
+	}
+	return key
+}
+
+// pushRandomImage pushes the random image to the in-process registry and returns its digest reference.
+func pushRandomImage(t *testing.T, host string) name.Digest {
+	t.Helper()
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatalf("failed to create random image: %v", err)
+	}
+	tag, err := name.NewTag(host + "/kyma-project/image:1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(tag, img); err != nil {
+		t.Fatalf("failed to push image: %v", err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tag.Context().Digest(digest.String())
+}
+
+// checkSignatureLayer checks the layer holds the simple signing payload of the image signed with the key.
+func checkSignatureLayer(t *testing.T, layer v1.Layer, annotations map[string]string, image name.Digest, pub crypto.PublicKey) {
+	t.Helper()
+	mediaType, err := layer.MediaType()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mediaType != CosignSimpleSigningMediaType {
+		t.Errorf("signature layer media type = %s, expected %s", mediaType, CosignSimpleSigningMediaType)
+	}
+	rc, err := layer.Uncompressed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	payload, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s SimpleSigning
+	if err := json.Unmarshal(payload, &s); err != nil {
+		t.Fatalf("failed to unmarshal payload: %v", err)
+	}
+	if s.Critical.Image.DockerManifestDigest != image.DigestStr() {
+		t.Errorf("payload digest = %s, expected %s", s.Critical.Image.DockerManifestDigest, image.DigestStr())
+	}
+	if s.Critical.Identity.DockerReference != image.Context().Name() {
+		t.Errorf("payload identity = %s, expected %s", s.Critical.Identity.DockerReference, image.Context().Name())
+	}
+	if s.Critical.Type != cosignSignatureType {
+		t.Errorf("payload type = %s, expected %s", s.Critical.Type, cosignSignatureType)
+	}
+	signature, err := base64.StdEncoding.DecodeString(annotations[CosignSignatureAnnotation])
+	if err != nil {
+		t.Fatalf("failed to decode signature annotation: %v", err)
+	}
+	if !verifyPayload(pub, payload, signature) {
+		t.Errorf("signature doesn't verify with the public key")
+	}
+}
+
+func TestParseCosignPrivateKey(t *testing.T) {
+	ecKey := newECDSAKey(t)
+	sec1, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := []struct {
+		name      string
+		data      []byte
+		expectErr bool
+	}{
+		{name: "ECDSA PKCS #8 key", data: pkcs8PEM(t, ecKey)},
+		{name: "ECDSA SEC 1 key", data: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1})},
+		{name: "ED25519 key", data: pkcs8PEM(t, newED25519Key(t))},
+		{name: "RSA key is not supported", data: pkcs8PEM(t, rsaKey), expectErr: true},
+		{name: "encrypted cosign key is not supported", data: pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED SIGSTORE PRIVATE KEY", Bytes: []byte("encrypted")}), expectErr: true},
+		{name: "not PEM data", data: []byte("not a key"), expectErr: true},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			key, err := ParseCosignPrivateKey(c.data)
+			if err != nil && !c.expectErr {
+				t.Errorf("got error but didn't want to: %v", err)
+			}
+			if err == nil && c.expectErr {
+				t.Errorf("didn't get error but wanted to")
+			}
+			if err == nil && key == nil {
+				t.Errorf("expected a key, got nil")
+			}
+		})
+	}
+}
+
+func TestCosignConfig_NewSigner(t *testing.T) {
+	keyPEM := pkcs8PEM(t, newECDSAKey(t))
+	keyPath := filepath.Join(t.TempDir(), "cosign.key")
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("COSIGN_TEST_KEY", string(keyPEM))
+
+	tc := []struct {
+		name         string
+		config       CosignConfig
+		expectedMode string
+		expectErr    bool
+	}{
+		{name: "key from file", config: CosignConfig{KeyPath: keyPath}, expectedMode: CosignSignatureModeTag},
+		{name: "key from environment variable", config: CosignConfig{KeyEnv: "COSIGN_TEST_KEY", SignatureMode: CosignSignatureModeReferrers}, expectedMode: CosignSignatureModeReferrers},
+		{name: "no key", config: CosignConfig{}, expectErr: true},
+		{name: "key file not found", config: CosignConfig{KeyPath: filepath.Join(t.TempDir(), "missing.key")}, expectErr: true},
+		{name: "environment variable not set", config: CosignConfig{KeyEnv: "COSIGN_TEST_KEY_MISSING"}, expectErr: true},
+		{name: "unsupported signature mode", config: CosignConfig{KeyPath: keyPath, SignatureMode: "rekor"}, expectErr: true},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			signer, err := c.config.NewSigner()
+			if err != nil && !c.expectErr {
+				t.Fatalf("got error but didn't want to: %v", err)
+			}
+			if err == nil && c.expectErr {
+				t.Fatalf("didn't get error but wanted to")
+			}
+			if c.expectErr {
+				return
+			}
+			if mode := signer.(*CosignSigner).mode; mode != c.expectedMode {
+				t.Errorf("signature mode = %s, expected %s", mode, c.expectedMode)
+			}
+		})
+	}
+}
+
+func TestCosignSigner_Sign_tag(t *testing.T) {
+	tc := []struct {
+		name string
+		key  crypto.Signer
+	}{
+		{name: "ECDSA key", key: newECDSAKey(t)},
+		{name: "ED25519 key", key: newED25519Key(t)},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			srv := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+			defer srv.Close()
+			image := pushRandomImage(t, strings.TrimPrefix(srv.URL, "http://"))
+			signer := &CosignSigner{key: c.key, mode: CosignSignatureModeTag}
+
+			// Sign by tag and by digest, the second signature is appended to the first one
+			tagged := image.Context().Tag("1.0.0").String()
+			if err := signer.Sign([]string{tagged, image.String()}); err != nil {
+				t.Fatalf("failed to sign image: %v", err)
+			}
+
+			hex := strings.TrimPrefix(image.DigestStr(), "sha256:")
+			sigImage, err := remote.Image(image.Context().Tag("sha256-" + hex + ".sig"))
+			if err != nil {
+				t.Fatalf("failed to fetch signature image: %v", err)
+			}
+			manifest, err := sigImage.Manifest()
+			if err != nil {
+				t.Fatal(err)
+			}
+			layers, err := sigImage.Layers()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(layers) != 2 {
+				t.Fatalf("expected 2 signature layers, got %d", len(layers))
+			}
+			for i, layer := range layers {
+				checkSignatureLayer(t, layer, manifest.Layers[i].Annotations, image, c.key.Public())
+			}
+		})
+	}
+}
+
+func TestCosignSigner_Sign_referrers(t *testing.T) {
+	tc := []struct {
+		name      string
+		referrers bool
+	}{
+		{name: "registry with referrers API", referrers: true},
+		{name: "registry without referrers API uses tag schema fallback", referrers: false},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			srv := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0)), registry.WithReferrersSupport(c.referrers)))
+			defer srv.Close()
+			image := pushRandomImage(t, strings.TrimPrefix(srv.URL, "http://"))
+			key := newECDSAKey(t)
+			signer := &CosignSigner{key: key, mode: CosignSignatureModeReferrers}
+
+			if err := signer.Sign([]string{image.String()}); err != nil {
+				t.Fatalf("failed to sign image: %v", err)
+			}
+
+			idx, err := remote.Referrers(image)
+			if err != nil {
+				t.Fatalf("failed to fetch referrers: %v", err)
+			}
+			referrers, err := idx.IndexManifest()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(referrers.Manifests) != 1 {
+				t.Fatalf("expected 1 referrer, got %d", len(referrers.Manifests))
+			}
+			if artifactType := referrers.Manifests[0].ArtifactType; artifactType != string(CosignSignatureArtifactType) {
+				t.Errorf("referrer artifact type = %s, expected %s", artifactType, CosignSignatureArtifactType)
+			}
+			sigImage, err := remote.Image(image.Context().Digest(referrers.Manifests[0].Digest.String()))
+			if err != nil {
+				t.Fatalf("failed to fetch signature artifact: %v", err)
+			}
+			manifest, err := sigImage.Manifest()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if manifest.Subject == nil || manifest.Subject.Digest.String() != image.DigestStr() {
+				t.Errorf("signature subject = %v, expected %s", manifest.Subject, image.DigestStr())
+			}
+			layers, err := sigImage.Layers()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkSignatureLayer(t, layers[0], manifest.Layers[0].Annotations, image, key.Public())
+		})
+	}
+}
+
+func TestCosignSigner_Sign_image_not_found(t *testing.T) {
+	srv := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer srv.Close()
+	signer := &CosignSigner{key: newECDSAKey(t), mode: CosignSignatureModeTag}
+
+	err := signer.Sign([]string{strings.TrimPrefix(srv.URL, "http://") + "/kyma-project/missing:1.0.0"})
+	if err == nil {
+		t.Errorf("expected error for missing image")
+	}
+}
+
+func TestCosignVerifier_Verify(t *testing.T) {
+	tc := []struct {
+		name string
+		mode string
+		key  crypto.Signer
+	}{
+		{name: "tag mode with ECDSA key", mode: CosignSignatureModeTag, key: newECDSAKey(t)},
+		{name: "tag mode with ED25519 key", mode: CosignSignatureModeTag, key: newED25519Key(t)},
+		{name: "referrers mode", mode: CosignSignatureModeReferrers, key: newECDSAKey(t)},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			srv := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0)), registry.WithReferrersSupport(true)))
+			defer srv.Close()
+			image := pushRandomImage(t, strings.TrimPrefix(srv.URL, "http://"))
+			tagged := image.Context().Tag("1.0.0").String()
+			verifier := &CosignVerifier{publicKey: c.key.Public(), mode: c.mode}
+
+			err := verifier.Verify(tagged)
+			if !errors.Is(err, ErrSignatureNotFound) {
+				t.Errorf("expected ErrSignatureNotFound for unsigned image, got: %v", err)
+			}
+
+			// Signature made with another key doesn't verify
+			other := &CosignSigner{key: newECDSAKey(t), mode: c.mode}
+			if err := other.Sign([]string{tagged}); err != nil {
+				t.Fatalf("failed to sign image: %v", err)
+			}
+			err = verifier.Verify(tagged)
+			if !errors.Is(err, ErrSignatureNotFound) {
+				t.Errorf("expected ErrSignatureNotFound for image signed with another key, got: %v", err)
+			}
+
+			signer := &CosignSigner{key: c.key, mode: c.mode}
+			if err := signer.Sign([]string{tagged}); err != nil {
+				t.Fatalf("failed to sign image: %v", err)
+			}
+			if err := verifier.Verify(tagged); err != nil {
+				t.Errorf("expected signed image to verify, got: %v", err)
+			}
+			if err := verifier.Verify(image.String()); err != nil {
+				t.Errorf("expected signed image referenced by digest to verify, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestCosignVerifier_Verify_signature_of_another_repository(t *testing.T) {
+	srv := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer srv.Close()
+	image := pushRandomImage(t, strings.TrimPrefix(srv.URL, "http://"))
+	key := newECDSAKey(t)
+	signer := &CosignSigner{key: key, mode: CosignSignatureModeTag}
+	if err := signer.Sign([]string{image.String()}); err != nil {
+		t.Fatalf("failed to sign image: %v", err)
+	}
+
+	// Copy the image and its signature to another repository, the digest stays the same
+	other, err := name.NewRepository(image.Context().RegistryStr() + "/kyma-project/other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hex := strings.TrimPrefix(image.DigestStr(), "sha256:")
+	for _, tag := range []string{"1.0.0", "sha256-" + hex + ".sig"} {
+		img, err := remote.Image(image.Context().Tag(tag))
+		if err != nil {
+			t.Fatalf("failed to fetch image %s: %v", tag, err)
+		}
+		if err := remote.Write(other.Tag(tag), img); err != nil {
+			t.Fatalf("failed to copy image %s: %v", tag, err)
+		}
+	}
+
+	verifier := &CosignVerifier{publicKey: key.Public(), mode: CosignSignatureModeTag}
+	if err := verifier.Verify(image.String()); err != nil {
+		t.Errorf("expected signed image to verify, got: %v", err)
+	}
+	err = verifier.Verify(other.Tag("1.0.0").String())
+	if !errors.Is(err, ErrSignatureNotFound) {
+		t.Errorf("expected ErrSignatureNotFound for signature of another repository, got: %v", err)
+	}
+}
+
+func TestCosignConfig_NewVerifier(t *testing.T) {
+	key := newECDSAKey(t)
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "cosign.key")
+	if err := os.WriteFile(keyPath, pkcs8PEM(t, key), 0600); err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubPath := filepath.Join(dir, "cosign.pub")
+	if err := os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tc := []struct {
+		name      string
+		config    CosignConfig
+		expectErr bool
+	}{
+		{name: "public key from file", config: CosignConfig{PublicKeyPath: pubPath}},
+		{name: "public key derived from private key", config: CosignConfig{KeyPath: keyPath}},
+		{name: "no key", config: CosignConfig{}, expectErr: true},
+		{name: "private key as public key", config: CosignConfig{PublicKeyPath: keyPath}, expectErr: true},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			verifier, err := c.config.NewVerifier()
+			if err != nil && !c.expectErr {
+				t.Fatalf("got error but didn't want to: %v", err)
+			}
+			if err == nil && c.expectErr {
+				t.Fatalf("didn't get error but wanted to")
+			}
+			if c.expectErr {
+				return
+			}
+			if !key.PublicKey.Equal(verifier.(*CosignVerifier).publicKey) {
+				t.Errorf("verifier public key doesn't match the key pair")
+			}
+		})
+	}
+}
@@ -1,16 +1,18 @@
-// Package sign provides functionality for signing container images using Notary v2.
+// Package sign provides functionality for signing container images using Notary v2 and cosign.
 package sign
 
 import (
 	"bytes"
 	"crypto/tls"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-containerregistry/pkg/authn"
@@ -18,6 +20,7 @@ import (
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/google"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	errutil "k8s.io/apimachinery/pkg/util/errors"
 )
 
 // ImageRepositoryInterface defines methods for parsing image references and fetching images.
@@ -184,91 +187,229 @@ type PayloadBuilderInterface interface {
 	BuildPayload(images []string) (SigningPayload, error)
 }
 
+// DefaultPayloadConcurrency is the number of images resolved in parallel by the PayloadBuilder, if Concurrency is not set.
+const DefaultPayloadConcurrency = 8
+
 // PayloadBuilder constructs the signing payload using an ImageRepositoryInterface.
 type PayloadBuilder struct {
 	ImageService ImageRepositoryInterface
+	// Concurrency limits the number of images resolved in parallel, DefaultPayloadConcurrency is used if it's not positive.
+	// The ImageService must be safe for concurrent use.
+	Concurrency int
+}
+
+// resolvedTarget is the result of resolving a single image reference.
+type resolvedTarget struct {
+	gun    string
+	target Target
+	err    error
 }
 
 // BuildPayload builds the signing payload for the given images.
+// Images can be referenced by tag, by digest, or by tag and digest, e.g. image:1.0.0@sha256:...
+// Targets of images from the same repository are merged into one trusted collection.
+// Images are resolved in parallel and identical references are resolved once.
+// Errors of all images are returned together. The order of GUNs and targets follows the order of images.
 func (pb *PayloadBuilder) BuildPayload(images []string) (SigningPayload, error) {
+	unique := uniqueImages(images)
+	results := pb.resolveTargets(unique)
+
+	var errs []error
 	var gunTargets []GUNTargets
-	for _, image := range images {
-		// Parse the image reference.
-		ref, err := pb.ImageService.ParseReference(image)
-		if err != nil {
-			return SigningPayload{}, fmt.Errorf("failed to parse image reference: %w", err)
+	gunIndex := make(map[string]int)
+	for i, image := range unique {
+		r := results[i]
+		if r.err != nil {
+			errs = append(errs, fmt.Errorf("image %s: %w", image, r.err))
+			continue
 		}
 
-		// Extract repository name and tag.
-		base := ref.Context().Name()
-		var tag string
-		if tagged, ok := ref.(name.Tag); ok {
-			tag = tagged.TagStr()
-		} else {
-			return SigningPayload{}, fmt.Errorf("reference is not a tag")
+		j, ok := gunIndex[r.gun]
+		if !ok {
+			gunIndex[r.gun] = len(gunTargets)
+			gunTargets = append(gunTargets, GUNTargets{GUN: r.gun, Targets: []Target{r.target}})
+			continue
 		}
-
-		isManifestList, err := pb.ImageService.IsManifestList(ref)
+		targets, err := appendTarget(gunTargets[j].Targets, r.target)
 		if err != nil {
-			return SigningPayload{}, fmt.Errorf("failed to check if reference is a manifest list: %w", err)
+			errs = append(errs, fmt.Errorf("image %s: %w", image, err))
+			continue
 		}
+		gunTargets[j].Targets = targets
+	}
+	if len(errs) > 0 {
+		return SigningPayload{}, errutil.NewAggregate(errs)
+	}
 
-		var digest string
-		var size int64
+	payload := SigningPayload{
+		GunTargets: gunTargets,
+	}
 
-		if isManifestList {
-			manifestList, err := pb.ImageService.GetManifestList(ref)
-			if err != nil {
-				return SigningPayload{}, fmt.Errorf("failed to fetch manifest list: %w", err)
-			}
+	return payload, nil
+}
 
-			digest, err = manifestList.GetDigest()
-			if err != nil {
-				return SigningPayload{}, fmt.Errorf("failed to get manifest list digest: %w", err)
+// resolveTargets resolves targets of the images with at most Concurrency workers.
+// Results are returned in the order of images.
+func (pb *PayloadBuilder) resolveTargets(images []string) []resolvedTarget {
+	concurrency := pb.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultPayloadConcurrency
+	}
+	concurrency = min(concurrency, len(images))
+
+	results := make([]resolvedTarget, len(images))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				gun, target, err := pb.buildTarget(images[i])
+				results[i] = resolvedTarget{gun: gun, target: target, err: err}
 			}
+		}()
+	}
+	for i := range images {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
 
-			size, err = manifestList.GetSize()
-			if err != nil {
-				return SigningPayload{}, fmt.Errorf("failed to get manifest list size: %w", err)
-			}
-		} else {
-			img, err := pb.ImageService.GetImage(ref)
-			if err != nil {
-				return SigningPayload{}, fmt.Errorf("failed to fetch image: %w", err)
-			}
+// uniqueImages returns images without duplicates, in the order of first occurrence.
+func uniqueImages(images []string) []string {
+	seen := make(map[string]bool, len(images))
+	var unique []string
+	for _, image := range images {
+		if seen[image] {
+			continue
+		}
+		seen[image] = true
+		unique = append(unique, image)
+	}
+	return unique
+}
 
-			digest, err = img.GetDigest()
-			if err != nil {
-				return SigningPayload{}, fmt.Errorf("failed to get image digest: %w", err)
-			}
+// appendTarget appends the target to the targets of the GUN, if it's not already there.
+// It returns an error if the target with the same name has a different digest or size.
+func appendTarget(targets []Target, target Target) ([]Target, error) {
+	for _, t := range targets {
+		if t.Name != target.Name {
+			continue
+		}
+		if t != target {
+			return nil, fmt.Errorf("target %s is requested with digests %s and %s", target.Name, t.Digest, target.Digest)
+		}
+		return targets, nil
+	}
+	return append(targets, target), nil
+}
 
-			size, err = img.GetSize()
-			if err != nil {
-				return SigningPayload{}, fmt.Errorf("failed to get image size: %w", err)
-			}
+// buildTarget returns the GUN and the target of the image.
+// The target of an image referenced by digest only is named after the digest.
+// If the reference contains the digest, the digest resolved from the registry must match it.
+func (pb *PayloadBuilder) buildTarget(image string) (string, Target, error) {
+	// Parse the image reference.
+	ref, err := pb.ImageService.ParseReference(image)
+	if err != nil {
+		return "", Target{}, fmt.Errorf("failed to parse image reference: %w", err)
+	}
+
+	// Extract repository name, tag and the expected digest.
+	base := ref.Context().Name()
+	var (
+		tag      string
+		expected string
+	)
+	switch r := ref.(type) {
+	case name.Tag:
+		tag = r.TagStr()
+	case name.Digest:
+		hash, err := v1.NewHash(r.DigestStr())
+		if err != nil {
+			return "", Target{}, fmt.Errorf("invalid digest of image %s: %w", image, err)
+		}
+		expected = hash.Hex
+		tag = r.DigestStr()
+		if t, ok := referenceTag(image); ok {
+			// Tag and digest reference, the tag is resolved and checked against the digest
+			tag = t
+			ref = r.Context().Tag(t)
 		}
+	default:
+		return "", Target{}, fmt.Errorf("reference is not a tag or digest")
+	}
 
-		// Build the target information.
-		target := Target{
-			Name:     tag,
-			ByteSize: size,
-			Digest:   digest,
+	digest, size, err := pb.resolve(ref)
+	if err != nil {
+		return "", Target{}, err
+	}
+	if expected != "" && digest != expected {
+		return "", Target{}, fmt.Errorf("image %s resolved to digest %s, expected %s", image, digest, expected)
+	}
+
+	// Build the target information.
+	target := Target{
+		Name:     tag,
+		ByteSize: size,
+		Digest:   digest,
+	}
+	return base, target, nil
+}
+
+// resolve returns the digest and size of the image or the manifest list.
+func (pb *PayloadBuilder) resolve(ref name.Reference) (string, int64, error) {
+	isManifestList, err := pb.ImageService.IsManifestList(ref)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to check if reference is a manifest list: %w", err)
+	}
+
+	if isManifestList {
+		manifestList, err := pb.ImageService.GetManifestList(ref)
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to fetch manifest list: %w", err)
 		}
 
-		// Build the GUN (Global Unique Name) targets.
-		gunTarget := GUNTargets{
-			GUN:     base,
-			Targets: []Target{target},
+		digest, err := manifestList.GetDigest()
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to get manifest list digest: %w", err)
 		}
 
-		gunTargets = append(gunTargets, gunTarget)
+		size, err := manifestList.GetSize()
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to get manifest list size: %w", err)
+		}
+		return digest, size, nil
 	}
 
-	payload := SigningPayload{
-		GunTargets: gunTargets,
+	img, err := pb.ImageService.GetImage(ref)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to fetch image: %w", err)
 	}
 
-	return payload, nil
+	digest, err := img.GetDigest()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get image digest: %w", err)
+	}
+
+	size, err := img.GetSize()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get image size: %w", err)
+	}
+	return digest, size, nil
+}
+
+// referenceTag returns the tag of the tag and digest reference, e.g. 1.0.0 for image:1.0.0@sha256:...
+// It returns false if the reference has no tag. The registry port is not taken as the tag.
+func referenceTag(image string) (string, bool) {
+	base, _, _ := strings.Cut(image, "@")
+	i := strings.LastIndex(base, ":")
+	if i < 0 || strings.Contains(base[i:], "/") {
+		return "", false
+	}
+	return base[i+1:], true
 }
 
 // TLSProviderInterface defines the method for obtaining TLS configuration.
@@ -491,6 +632,90 @@ func (nc *NotaryConfig) NewSigner() (Signer, error) {
 	return signer, nil
 }
 
+// NewVerifier constructs a new NotaryVerifier checking trust data on the Notary server set in trust-server.
+func (nc *NotaryConfig) NewVerifier() (Verifier, error) {
+	if nc.TrustServer == "" {
+		return nil, fmt.Errorf("trust-server must be set to verify notary signatures")
+	}
+	return &NotaryVerifier{
+		trustServer: strings.TrimSuffix(nc.TrustServer, "/"),
+		payloadBuilder: &PayloadBuilder{
+			ImageService: NewImageService(),
+		},
+		httpClient: &HTTPClient{
+			Client: &http.Client{
+				Timeout: nc.Timeout,
+			},
+		},
+	}, nil
+}
+
+// NotaryVerifier checks that image tags are signed by looking them up in the trust data of the Notary server.
+// The target signed for the tag must have the same digest and size as the image in the registry.
+type NotaryVerifier struct {
+	trustServer    string
+	payloadBuilder PayloadBuilderInterface
+	httpClient     HTTPClientInterface
+}
+
+// TrustTargets is the part of the TUF targets metadata served by the Notary server, which lists signed targets.
+type TrustTargets struct {
+	Signed struct {
+		Targets map[string]TrustTarget `json:"targets"`
+	} `json:"signed"`
+}
+
+// TrustTarget is the target signed for the tag.
+type TrustTarget struct {
+	// Hashes maps hash algorithms to the digest of the image manifest
+	Hashes map[string][]byte `json:"hashes"`
+	// Length is the size of the image manifest
+	Length int64 `json:"length"`
+}
+
+// Verify returns nil if the image tag is signed with the digest and size of the image.
+// The image must be referenced by tag, the same as for signing.
+func (nv *NotaryVerifier) Verify(image string) error {
+	payload, err := nv.payloadBuilder.BuildPayload([]string{image})
+	if err != nil {
+		return fmt.Errorf("failed to build payload: %w", err)
+	}
+	gunTarget := payload.GunTargets[0]
+	target := gunTarget.Targets[0]
+
+	url := fmt.Sprintf("%s/v2/%s/_trust/tuf/targets.json", nv.trustServer, gunTarget.GUN)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	resp, err := nv.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch trust data: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: no trust data for %s", ErrSignatureNotFound, gunTarget.GUN)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch trust data: unexpected status code: %d", resp.StatusCode)
+	}
+	var trust TrustTargets
+	if err := json.NewDecoder(resp.Body).Decode(&trust); err != nil {
+		return fmt.Errorf("failed to parse trust data: %w", err)
+	}
+
+	signed, ok := trust.Signed.Targets[target.Name]
+	if !ok {
+		return fmt.Errorf("%w: tag %s is not signed in %s", ErrSignatureNotFound, target.Name, gunTarget.GUN)
+	}
+	signedDigest := hex.EncodeToString(signed.Hashes["sha256"])
+	if signedDigest != target.Digest || signed.Length != target.ByteSize {
+		return fmt.Errorf("%w: tag %s is signed with digest %s and size %d, the image has digest %s and size %d",
+			ErrSignatureNotFound, target.Name, signedDigest, signed.Length, target.Digest, target.ByteSize)
+	}
+	return nil
+}
+
 // Target represents an individual image target to be signed.
 type Target struct {
 	Name     string `json:"name"`
@@ -515,12 +740,26 @@ type TLSCredentials struct {
 	PrivateKeyData  string `json:"privateKeyData"`
 }
 
+func init() {
+	RegisterBackend(TypeNotaryBackend, decodeNotaryConfig)
+}
+
+// decodeNotaryConfig decodes the config of the notary signer.
+func decodeNotaryConfig(decode func(v any) error) (SignerFactory, error) {
+	var nc NotaryConfig
+	if err := decode(&nc); err != nil {
+		return nil, err
+	}
+	return &nc, nil
+}
+
 // NotaryConfig holds the configuration for the NotarySigner.
 type NotaryConfig struct {
 	Endpoint     string            `yaml:"endpoint" json:"endpoint"`
 	Secret       *AuthSecretConfig `yaml:"secret,omitempty" json:"secret,omitempty"`
 	Timeout      time.Duration     `yaml:"timeout" json:"timeout"`
 	RetryTimeout time.Duration     `yaml:"retry-timeout" json:"retry-timeout"`
+	TrustServer  string            `yaml:"trust-server,omitempty" json:"trust-server,omitempty"`
 }
 
 // AuthSecretConfig specifies the path and type of the secret containing TLS credentials.
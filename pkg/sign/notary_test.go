@@ -8,8 +8,10 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -17,10 +19,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"reflect"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/go-containerregistry/pkg/name"
+	errutil "k8s.io/apimachinery/pkg/util/errors"
 )
 
 // generateTestCert generates a self-signed certificate and private key.
@@ -225,6 +230,219 @@ func TestPayloadBuilder_BuildPayload_Invalid(t *testing.T) {
 	}
 }
 
+// TestPayloadBuilder_BuildPayload_References checks building a payload for tag and digest references of images.
+func TestPayloadBuilder_BuildPayload_References(t *testing.T) {
+	const (
+		digestA = "1111111111111111111111111111111111111111111111111111111111111111"
+		digestB = "2222222222222222222222222222222222222222222222222222222222222222"
+	)
+	// digests maps the fetched reference to the digest of the image
+	digests := map[string]string{
+		"europe-docker.pkg.dev/kyma-project/prod/image:1.0.0":             digestA,
+		"europe-docker.pkg.dev/kyma-project/prod/image:latest":            digestA,
+		"europe-docker.pkg.dev/kyma-project/prod/image:2.0.0":             digestB,
+		"europe-docker.pkg.dev/kyma-project/prod/image@sha256:" + digestA: digestA,
+		"europe-docker.pkg.dev/kyma-project/prod/other:1.0.0":             digestB,
+		"localhost:5000/image:1.0.0":                                      digestA,
+	}
+	mockImageRepository := &MockImageRepository{
+		MockParseReference: func(image string) (name.Reference, error) {
+			return name.ParseReference(image)
+		},
+		MockGetImage: func(ref name.Reference) (ImageInterface, error) {
+			digest, ok := digests[ref.Name()]
+			if !ok {
+				return nil, fmt.Errorf("image %s not found", ref.Name())
+			}
+			return &MockImage{
+				MockGetDigest: func() (string, error) { return digest, nil },
+				MockGetSize:   func() (int64, error) { return 2048, nil },
+			}, nil
+		},
+		MockIsManifestList: func(name.Reference) (bool, error) { return false, nil },
+	}
+	payloadBuilder := PayloadBuilder{ImageService: mockImageRepository}
+
+	tc := []struct {
+		name      string
+		images    []string
+		expected  []GUNTargets
+		expectErr bool
+	}{
+		{
+			name:   "digest reference",
+			images: []string{"europe-docker.pkg.dev/kyma-project/prod/image@sha256:" + digestA},
+			expected: []GUNTargets{{GUN: "europe-docker.pkg.dev/kyma-project/prod/image", Targets: []Target{
+				{Name: "sha256:" + digestA, ByteSize: 2048, Digest: digestA},
+			}}},
+		},
+		{
+			name:   "tag and digest reference",
+			images: []string{"europe-docker.pkg.dev/kyma-project/prod/image:1.0.0@sha256:" + digestA},
+			expected: []GUNTargets{{GUN: "europe-docker.pkg.dev/kyma-project/prod/image", Targets: []Target{
+				{Name: "1.0.0", ByteSize: 2048, Digest: digestA},
+			}}},
+		},
+		{
+			name:   "tag and digest reference with registry port",
+			images: []string{"localhost:5000/image:1.0.0@sha256:" + digestA},
+			expected: []GUNTargets{{GUN: "localhost:5000/image", Targets: []Target{
+				{Name: "1.0.0", ByteSize: 2048, Digest: digestA},
+			}}},
+		},
+		{
+			name:      "tag resolved to different digest",
+			images:    []string{"europe-docker.pkg.dev/kyma-project/prod/image:2.0.0@sha256:" + digestA},
+			expectErr: true,
+		},
+		{
+			name: "targets grouped per repository",
+			images: []string{
+				"europe-docker.pkg.dev/kyma-project/prod/image:1.0.0",
+				"europe-docker.pkg.dev/kyma-project/prod/other:1.0.0",
+				"europe-docker.pkg.dev/kyma-project/prod/image:latest@sha256:" + digestA,
+				"europe-docker.pkg.dev/kyma-project/prod/image:1.0.0",
+			},
+			expected: []GUNTargets{
+				{GUN: "europe-docker.pkg.dev/kyma-project/prod/image", Targets: []Target{
+					{Name: "1.0.0", ByteSize: 2048, Digest: digestA},
+					{Name: "latest", ByteSize: 2048, Digest: digestA},
+				}},
+				{GUN: "europe-docker.pkg.dev/kyma-project/prod/other", Targets: []Target{
+					{Name: "1.0.0", ByteSize: 2048, Digest: digestB},
+				}},
+			},
+		},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			payload, err := payloadBuilder.BuildPayload(c.images)
+			if err != nil && !c.expectErr {
+				t.Errorf("got error but didn't want to: %v", err)
+			}
+			if err == nil && c.expectErr {
+				t.Errorf("didn't get error but wanted to")
+			}
+			if !reflect.DeepEqual(payload.GunTargets, c.expected) {
+				t.Errorf("BuildPayload() = %+v, expected %+v", payload.GunTargets, c.expected)
+			}
+		})
+	}
+}
+
+// TestPayloadBuilder_BuildPayload_Concurrent checks that images are resolved in parallel with bounded concurrency,
+// identical references are resolved once, and the payload follows the order of images.
+func TestPayloadBuilder_BuildPayload_Concurrent(t *testing.T) {
+	const concurrency = 3
+	var (
+		mu       sync.Mutex
+		running  int
+		peak     int
+		resolved = map[string]int{}
+	)
+	mockImageRepository := &MockImageRepository{
+		MockParseReference: func(image string) (name.Reference, error) {
+			return name.ParseReference(image)
+		},
+		MockGetImage: func(ref name.Reference) (ImageInterface, error) {
+			mu.Lock()
+			running++
+			peak = max(peak, running)
+			resolved[ref.Name()]++
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return &MockImage{
+				MockGetDigest: func() (string, error) { return "digest-" + ref.Identifier(), nil },
+				MockGetSize:   func() (int64, error) { return 2048, nil },
+			}, nil
+		},
+		MockIsManifestList: func(name.Reference) (bool, error) { return false, nil },
+	}
+	payloadBuilder := PayloadBuilder{ImageService: mockImageRepository, Concurrency: concurrency}
+
+	var images []string
+	var expected []GUNTargets
+	for i := range 10 {
+		repo := fmt.Sprintf("europe-docker.pkg.dev/kyma-project/prod/image-%d", i)
+		images = append(images, repo+":1.0.0", repo+":1.0.0")
+		expected = append(expected, GUNTargets{GUN: repo, Targets: []Target{{Name: "1.0.0", ByteSize: 2048, Digest: "digest-1.0.0"}}})
+	}
+
+	payload, err := payloadBuilder.BuildPayload(images)
+	if err != nil {
+		t.Fatalf("got error but didn't want to: %v", err)
+	}
+	if !reflect.DeepEqual(payload.GunTargets, expected) {
+		t.Errorf("BuildPayload() = %+v, expected %+v", payload.GunTargets, expected)
+	}
+	if peak > concurrency {
+		t.Errorf("%d images were resolved in parallel, expected at most %d", peak, concurrency)
+	}
+	for image, count := range resolved {
+		if count != 1 {
+			t.Errorf("image %s was resolved %d times, expected once", image, count)
+		}
+	}
+	if len(resolved) != 10 {
+		t.Errorf("%d images were resolved, expected 10", len(resolved))
+	}
+}
+
+// TestPayloadBuilder_BuildPayload_AggregatedErrors checks that errors of all images are returned.
+func TestPayloadBuilder_BuildPayload_AggregatedErrors(t *testing.T) {
+	mockImageRepository := &MockImageRepository{
+		MockParseReference: func(image string) (name.Reference, error) {
+			return name.ParseReference(image)
+		},
+		MockGetImage: func(ref name.Reference) (ImageInterface, error) {
+			if ref.Identifier() == "missing" {
+				return nil, fmt.Errorf("not found")
+			}
+			return &MockImage{
+				MockGetDigest: func() (string, error) { return "dummy-manifest-digest", nil },
+				MockGetSize:   func() (int64, error) { return 2048, nil },
+			}, nil
+		},
+		MockIsManifestList: func(name.Reference) (bool, error) { return false, nil },
+	}
+	payloadBuilder := PayloadBuilder{ImageService: mockImageRepository}
+
+	_, err := payloadBuilder.BuildPayload([]string{
+		"europe-docker.pkg.dev/kyma-project/prod/first:missing",
+		"europe-docker.pkg.dev/kyma-project/prod/image:1.0.0",
+		"europe-docker.pkg.dev/kyma-project/prod/second:missing",
+	})
+	if err == nil {
+		t.Fatalf("didn't get error but wanted to")
+	}
+	var agg errutil.Aggregate
+	if !errors.As(err, &agg) {
+		t.Fatalf("expected aggregated error, got: %v", err)
+	}
+	expected := []string{
+		"image europe-docker.pkg.dev/kyma-project/prod/first:missing: failed to fetch image: not found",
+		"image europe-docker.pkg.dev/kyma-project/prod/second:missing: failed to fetch image: not found",
+	}
+	var got []string
+	for _, e := range agg.Errors() {
+		got = append(got, e.Error())
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("BuildPayload() errors = %q, expected %q", got, expected)
+	}
+}
+
+// TestAppendTarget_conflicting_digest checks that the same target can't be signed with different digests.
+func TestAppendTarget_conflicting_digest(t *testing.T) {
+	targets := []Target{{Name: "1.0.0", ByteSize: 2048, Digest: "digest-a"}}
+	if _, err := appendTarget(targets, Target{Name: "1.0.0", ByteSize: 2048, Digest: "digest-b"}); err == nil {
+		t.Errorf("didn't get error but wanted to")
+	}
+}
+
 // TestTLSProvider_GetTLSConfig_Valid checks getting TLS configuration with valid credentials.
 func TestTLSProvider_GetTLSConfig_Valid(t *testing.T) {
 	certPEM, keyPEM, err := generateTestCert()
@@ -644,3 +862,87 @@ func TestNotarySigner_Sign_ManifestList(t *testing.T) {
 		t.Fatalf("Signing failed: %v", err)
 	}
 }
+
+func TestNotaryVerifier_Verify(t *testing.T) {
+	const gun = "europe-docker.pkg.dev/kyma-project/prod/image"
+	digest := "5b0bcabd1ed22e9fb1310cf6c2dec7cdef19f0ad69efa1f392e94a4333501270"
+	digestBytes, _ := hex.DecodeString(digest)
+	trustData := TrustTargets{}
+	trustData.Signed.Targets = map[string]TrustTarget{
+		"1.0.0": {Hashes: map[string][]byte{"sha256": digestBytes}, Length: 1024},
+		"1.0.1": {Hashes: map[string][]byte{"sha256": digestBytes}, Length: 2048},
+	}
+
+	// Fake Notary server serving trust data of the single GUN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/"+gun+"/_trust/tuf/targets.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(trustData)
+	}))
+	defer server.Close()
+
+	payloadBuilder := &MockPayloadBuilder{
+		MockBuildPayload: func(images []string) (SigningPayload, error) {
+			ref, err := name.NewTag(images[0])
+			if err != nil {
+				return SigningPayload{}, err
+			}
+			return SigningPayload{GunTargets: []GUNTargets{{
+				GUN:     ref.Context().Name(),
+				Targets: []Target{{Name: ref.TagStr(), ByteSize: 1024, Digest: digest}},
+			}}}, nil
+		},
+	}
+
+	tc := []struct {
+		name        string
+		image       string
+		expectErr   bool
+		notSigned   bool
+		trustServer string
+	}{
+		{name: "signed tag", image: gun + ":1.0.0"},
+		{name: "tag not signed", image: gun + ":2.0.0", expectErr: true, notSigned: true},
+		{name: "signed tag has different size", image: gun + ":1.0.1", expectErr: true, notSigned: true},
+		{name: "no trust data for repository", image: "europe-docker.pkg.dev/kyma-project/prod/other:1.0.0", expectErr: true, notSigned: true},
+		{name: "trust server not reachable", image: gun + ":1.0.0", trustServer: "http://127.0.0.1:1", expectErr: true},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			trustServer := server.URL
+			if c.trustServer != "" {
+				trustServer = c.trustServer
+			}
+			verifier := &NotaryVerifier{
+				trustServer:    trustServer,
+				payloadBuilder: payloadBuilder,
+				httpClient:     &HTTPClient{Client: &http.Client{Timeout: 5 * time.Second}},
+			}
+			err := verifier.Verify(c.image)
+			if err != nil && !c.expectErr {
+				t.Errorf("got error but didn't want to: %v", err)
+			}
+			if err == nil && c.expectErr {
+				t.Errorf("didn't get error but wanted to")
+			}
+			if errors.Is(err, ErrSignatureNotFound) != c.notSigned {
+				t.Errorf("errors.Is(err, ErrSignatureNotFound) = %t, expected %t, err: %v", !c.notSigned, c.notSigned, err)
+			}
+		})
+	}
+}
+
+func TestNotaryConfig_NewVerifier(t *testing.T) {
+	if _, err := (&NotaryConfig{}).NewVerifier(); err == nil {
+		t.Errorf("expected error when trust-server is not set")
+	}
+	verifier, err := (&NotaryConfig{TrustServer: "https://notary.example.com/"}).NewVerifier()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if url := verifier.(*NotaryVerifier).trustServer; url != "https://notary.example.com" {
+		t.Errorf("trust server = %s, expected https://notary.example.com", url)
+	}
+}
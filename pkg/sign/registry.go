@@ -0,0 +1,63 @@
+package sign
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// ConfigDecoder creates the SignerFactory from the backend specific config of the signer.
+// The decode function unmarshals the config section into the value passed to it,
+// so the same decoder is used for YAML and JSON configs.
+type ConfigDecoder func(decode func(v any) error) (SignerFactory, error)
+
+var (
+	backendsMu sync.RWMutex
+	// backends maps signer types to decoders of their configs
+	backends = map[string]ConfigDecoder{}
+)
+
+// RegisterBackend makes the signing backend available under the signer type used in the type field of SignerConfig.
+// It's intended to be called from the init function of the file implementing the backend.
+// It panics if the type is empty, the decoder is nil, or the type is already registered.
+func RegisterBackend(signerType string, decoder ConfigDecoder) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if signerType == "" {
+		panic("sign: RegisterBackend signer type is empty")
+	}
+	if decoder == nil {
+		panic("sign: RegisterBackend decoder is nil for type " + signerType)
+	}
+	if _, exists := backends[signerType]; exists {
+		panic("sign: RegisterBackend called twice for type " + signerType)
+	}
+	backends[signerType] = decoder
+}
+
+// RegisteredTypes returns the sorted list of registered signer types.
+func RegisteredTypes() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	types := make([]string, 0, len(backends))
+	for t := range backends {
+		types = append(types, t)
+	}
+	slices.Sort(types)
+	return types
+}
+
+// decodeSignerConfig returns the SignerFactory of the signer type decoded with the decoder registered for it.
+func decodeSignerConfig(signerType string, decode func(v any) error) (SignerFactory, error) {
+	backendsMu.RLock()
+	decoder, ok := backends[signerType]
+	backendsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown signer type %q, supported types: %v", signerType, RegisteredTypes())
+	}
+	factory, err := decoder(decode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode config of %s signer: %w", signerType, err)
+	}
+	return factory, nil
+}
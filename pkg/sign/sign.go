@@ -1,11 +1,16 @@
 package sign
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
 	"gopkg.in/yaml.v3"
 )
 
 const (
 	TypeNotaryBackend = "notary"
+	TypeCosignBackend = "cosign"
 )
 
 type SignerConfig struct {
@@ -30,6 +35,24 @@ type Signer interface {
 	Sign([]string) error
 }
 
+// ErrSignatureNotFound is wrapped by errors returned from Verifier when the image has no valid signature.
+var ErrSignatureNotFound = errors.New("signature not found")
+
+// VerifierFactory is implemented by signer configs of backends able to verify their signatures.
+type VerifierFactory interface {
+	NewVerifier() (Verifier, error)
+}
+
+// Verifier checks that the image is signed by the signing backend.
+type Verifier interface {
+	// Verify returns nil if the image has a valid signature.
+	// The returned error wraps ErrSignatureNotFound if the signature is missing or doesn't match the image.
+	// Other errors mean the signature couldn't be checked, e.g. the registry is not reachable.
+	Verify(image string) error
+}
+
+// UnmarshalYAML decodes the signer config with the decoder registered for the signer type.
+// It returns an error if the type is not registered.
 func (sc *SignerConfig) UnmarshalYAML(value *yaml.Node) error {
 	var t struct {
 		Name    string    `yaml:"name"`
@@ -41,15 +64,51 @@ func (sc *SignerConfig) UnmarshalYAML(value *yaml.Node) error {
 		return err
 	}
 
+	config, err := decodeSignerConfig(t.Type, func(v any) error {
+		if t.Config.IsZero() {
+			return nil
+		}
+		return t.Config.Decode(v)
+	})
+	if err != nil {
+		return fmt.Errorf("signer %s: %w", t.Name, err)
+	}
+
 	sc.Type = t.Type
 	sc.Name = t.Name
 	sc.JobType = t.JobType
+	sc.Config = config
 
-	var notaryConfig NotaryConfig
-	if err := t.Config.Decode(&notaryConfig); err != nil {
+	return nil
+}
+
+// UnmarshalJSON decodes the signer config with the decoder registered for the signer type.
+// It returns an error if the type is not registered.
+func (sc *SignerConfig) UnmarshalJSON(data []byte) error {
+	var t struct {
+		Name    string          `json:"name"`
+		Type    string          `json:"type"`
+		JobType []string        `json:"job-type"`
+		Config  json.RawMessage `json:"config"`
+	}
+	if err := json.Unmarshal(data, &t); err != nil {
 		return err
 	}
-	sc.Config = &notaryConfig
+
+	config, err := decodeSignerConfig(t.Type, func(v any) error {
+		if len(t.Config) == 0 || string(t.Config) == "null" {
+			return nil
+		}
+		return json.Unmarshal(t.Config, v)
+	})
+	if err != nil {
+		return fmt.Errorf("signer %s: %w", t.Name, err)
+	}
+
+	sc.Type = t.Type
+	sc.Name = t.Name
+	sc.JobType = t.JobType
+	sc.Config = config
 
 	return nil
 }
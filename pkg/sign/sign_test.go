@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -68,6 +69,138 @@ config:
 	}
 }
 
+func TestNotaryConfigUnmarshalJSON(t *testing.T) {
+	jsonData := `{
+  "name": "notary-signer",
+  "type": "notary",
+  "job-type": ["postsubmit"],
+  "config": {
+    "endpoint": "https://notary.example.com",
+    "secret": {"path": "/path/to/secret", "type": "signify"},
+    "timeout": 10000000000
+  }
+}`
+
+	var sc SignerConfig
+	if err := json.Unmarshal([]byte(jsonData), &sc); err != nil {
+		t.Fatalf("failed to unmarshal JSON: %v", err)
+	}
+
+	if sc.Name != "notary-signer" || sc.Type != "notary" || len(sc.JobType) != 1 {
+		t.Errorf("unexpected signer config fields: %+v", sc)
+	}
+	notaryConfig, ok := sc.Config.(*NotaryConfig)
+	if !ok {
+		t.Fatalf("expected sc.Config to be of type *NotaryConfig, but got %T", sc.Config)
+	}
+	if notaryConfig.Endpoint != "https://notary.example.com" {
+		t.Errorf("expected endpoint to be 'https://notary.example.com', got %s", notaryConfig.Endpoint)
+	}
+	if notaryConfig.Secret == nil || notaryConfig.Secret.Type != "signify" {
+		t.Errorf("expected secret type to be 'signify', got %v", notaryConfig.Secret)
+	}
+	if notaryConfig.Timeout != 10*time.Second {
+		t.Errorf("expected timeout to be 10s, got %v", notaryConfig.Timeout)
+	}
+}
+
+// testSignerConfig is the config of the signing backend registered only in tests.
+type testSignerConfig struct {
+	Key string `yaml:"key" json:"key"`
+}
+
+func (c *testSignerConfig) NewSigner() (Signer, error) {
+	return nil, nil
+}
+
+func init() {
+	RegisterBackend("test", func(decode func(v any) error) (SignerFactory, error) {
+		var c testSignerConfig
+		if err := decode(&c); err != nil {
+			return nil, err
+		}
+		return &c, nil
+	})
+}
+
+func TestSignerConfig_Unmarshal_type_dispatch(t *testing.T) {
+	tc := []struct {
+		name      string
+		yaml      string
+		json      string
+		expected  SignerFactory
+		expectErr bool
+	}{
+		{
+			name:     "registered backend",
+			yaml:     "name: custom\ntype: test\nconfig:\n  key: value\n",
+			json:     `{"name": "custom", "type": "test", "config": {"key": "value"}}`,
+			expected: &testSignerConfig{Key: "value"},
+		},
+		{
+			name:     "registered backend without config",
+			yaml:     "name: custom\ntype: test\n",
+			json:     `{"name": "custom", "type": "test"}`,
+			expected: &testSignerConfig{},
+		},
+		{
+			name:      "unknown type",
+			yaml:      "name: custom\ntype: unknown\nconfig:\n  key: value\n",
+			json:      `{"name": "custom", "type": "unknown", "config": {"key": "value"}}`,
+			expectErr: true,
+		},
+		{
+			name:      "missing type",
+			yaml:      "name: custom\nconfig:\n  key: value\n",
+			json:      `{"name": "custom", "config": {"key": "value"}}`,
+			expectErr: true,
+		},
+		{
+			name:      "malformed config",
+			yaml:      "name: custom\ntype: test\nconfig:\n  - value\n",
+			json:      `{"name": "custom", "type": "test", "config": ["value"]}`,
+			expectErr: true,
+		},
+	}
+	for _, c := range tc {
+		formats := map[string]func(*SignerConfig) error{
+			"yaml": func(sc *SignerConfig) error { return yaml.Unmarshal([]byte(c.yaml), sc) },
+			"json": func(sc *SignerConfig) error { return json.Unmarshal([]byte(c.json), sc) },
+		}
+		for format, unmarshal := range formats {
+			t.Run(c.name+" "+format, func(t *testing.T) {
+				var sc SignerConfig
+				err := unmarshal(&sc)
+				if err != nil && !c.expectErr {
+					t.Fatalf("got error but didn't want to: %v", err)
+				}
+				if err == nil && c.expectErr {
+					t.Fatalf("didn't get error but wanted to")
+				}
+				if !reflect.DeepEqual(sc.Config, c.expected) {
+					t.Errorf("Config = %#v, expected %#v", sc.Config, c.expected)
+				}
+			})
+		}
+	}
+}
+
+func TestRegisteredTypes(t *testing.T) {
+	expected := []string{"cosign", "notary", "test"}
+	if got := RegisteredTypes(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("RegisteredTypes() = %v, expected %v", got, expected)
+	}
+}
+
+func TestRegisterBackend_duplicate_type_panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic when registering the type twice")
+		}
+	}()
+	RegisterBackend(TypeNotaryBackend, decodeNotaryConfig)
+}
+
 func TestNotaryConfig_NewSigner(t *testing.T) {
 	certPEM, keyPEM, err := generateTestCert()
 	if err != nil {
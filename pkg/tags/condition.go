@@ -0,0 +1,73 @@
+package tags
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Condition limits a tag to the git context it's used in, e.g. latest only for postsubmits on the main branch.
+// All set fields must match for the condition to be met. A condition without fields is always met.
+type Condition struct {
+	// JobTypes lists CI job types the tag is used for, e.g. postsubmit or schedule
+	JobTypes []string `yaml:"job-types,omitempty" json:"job-types,omitempty"`
+	// Ref is a regex matched against the base git ref, e.g. ^refs/heads/main$ or ^refs/tags/
+	Ref string `yaml:"ref,omitempty" json:"ref,omitempty"`
+	// Repository is a glob matched against the repository in the owner/name format, e.g. kyma-project/*
+	Repository string `yaml:"repository,omitempty" json:"repository,omitempty"`
+}
+
+// Validate returns an error if the ref regex or the repository glob is malformed.
+func (c Condition) Validate() error {
+	if _, err := regexp.Compile(c.Ref); err != nil {
+		return fmt.Errorf("invalid condition ref regex %s: %w", c.Ref, err)
+	}
+	if _, err := path.Match(c.Repository, ""); err != nil {
+		return fmt.Errorf("invalid condition repository pattern %s: %w", c.Repository, err)
+	}
+	return nil
+}
+
+// evaluate checks the condition against the git context of the Tagger.
+// It returns the reason if the condition isn't met, or an empty string if it is.
+// It returns an error if the condition limits refs, but the git ref of the Tagger is empty.
+// Raw values of Tagger fields are used, not the sanitized ones.
+func (c Condition) evaluate(tg *Tagger) (string, error) {
+	if len(c.JobTypes) > 0 && !slices.Contains(c.JobTypes, string(tg.JobType)) {
+		return fmt.Sprintf("job type %s is not one of %s", tg.JobType, strings.Join(c.JobTypes, ", ")), nil
+	}
+	if c.Ref != "" {
+		// A tag limited to refs can't be skipped silently, when the CI system doesn't provide the ref
+		if tg.BaseRef == "" {
+			return "", fmt.Errorf("condition ref %s can't be evaluated, the git ref is not known", c.Ref)
+		}
+		re, err := regexp.Compile(c.Ref)
+		if err != nil {
+			return "", fmt.Errorf("invalid condition ref regex %s: %w", c.Ref, err)
+		}
+		if !re.MatchString(string(tg.BaseRef)) {
+			return fmt.Sprintf("ref %s doesn't match %s", tg.BaseRef, c.Ref), nil
+		}
+	}
+	if c.Repository != "" {
+		repository := string(tg.RepoOwner) + "/" + string(tg.RepoName)
+		matched, err := path.Match(c.Repository, repository)
+		if err != nil {
+			return "", fmt.Errorf("invalid condition repository pattern %s: %w", c.Repository, err)
+		}
+		if !matched {
+			return fmt.Sprintf("repository %s doesn't match %s", repository, c.Repository), nil
+		}
+	}
+	return "", nil
+}
+
+// SkippedTag is a tag not parsed by ParseTags, because its condition isn't met.
+type SkippedTag struct {
+	// Tag is the tag definition
+	Tag Tag
+	// Reason describes which part of the condition isn't met
+	Reason string
+}
@@ -0,0 +1,121 @@
+package tags
+
+import (
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestTagger_ParseTags_conditions(t *testing.T) {
+	mainPostsubmit := GitState{
+		RepositoryOwner: "kyma-project",
+		RepositoryName:  "test-infra",
+		JobType:         "postsubmit",
+		BaseCommitRef:   "refs/heads/main",
+		BaseCommitSHA:   "f1c7ca0b532141898f56c1843ae60ebec3a75a85",
+	}
+	tc := []struct {
+		name        string
+		gitState    GitState
+		tags        []Tag
+		expected    []Tag
+		expSkipped  []string
+		expectedErr bool
+	}{
+		{
+			name:     "all conditions met",
+			gitState: mainPostsubmit,
+			tags: []Tag{
+				{Name: "latest", Value: "latest", When: &Condition{JobTypes: []string{"postsubmit"}, Ref: `^refs/heads/main$`, Repository: "kyma-project/*"}},
+				{Name: "commit", Value: "{{ .ShortSHA }}"},
+			},
+			expected: []Tag{{Name: "latest", Value: "latest"}, {Name: "commit", Value: "f1c7ca0b"}},
+		},
+		{
+			name:     "job type not matched",
+			gitState: mainPostsubmit,
+			tags: []Tag{
+				{Name: "nightly", Value: "nightly-{{ .Date }}", When: &Condition{JobTypes: []string{"schedule"}}},
+				{Name: "commit", Value: "{{ .ShortSHA }}"},
+			},
+			expected:   []Tag{{Name: "commit", Value: "f1c7ca0b"}},
+			expSkipped: []string{"nightly"},
+		},
+		{
+			name:     "ref not matched",
+			gitState: GitState{JobType: "postsubmit", BaseCommitRef: "refs/heads/release-1.0", BaseCommitSHA: "f1c7ca0b532141898f56c1843ae60ebec3a75a85"},
+			tags: []Tag{
+				{Name: "latest", Value: "latest", When: &Condition{Ref: `^refs/heads/main$`}},
+				{Name: "release", Value: "{{ .GitTag }}", When: &Condition{Ref: `^refs/tags/`}},
+				{Name: "commit", Value: "{{ .ShortSHA }}"},
+			},
+			expected:   []Tag{{Name: "commit", Value: "f1c7ca0b"}},
+			expSkipped: []string{"latest", "release"},
+		},
+		{
+			name:     "repository not matched",
+			gitState: mainPostsubmit,
+			tags: []Tag{
+				{Name: "latest", Value: "latest", When: &Condition{Repository: "kyma-incubator/*"}},
+				{Name: "commit", Value: "{{ .ShortSHA }}"},
+			},
+			expected:   []Tag{{Name: "commit", Value: "f1c7ca0b"}},
+			expSkipped: []string{"latest"},
+		},
+		{
+			name:     "skipped tags are not validated",
+			gitState: mainPostsubmit,
+			tags: []Tag{
+				{Name: "release", Value: "{{ .GitTag }}", When: &Condition{Ref: `^refs/tags/`}},
+				{Name: "release", Value: "{{ .ShortSHA }}"},
+			},
+			expected:   []Tag{{Name: "release", Value: "f1c7ca0b"}},
+			expSkipped: []string{"release"},
+		},
+		{
+			name:     "fail, ref condition without git ref",
+			gitState: GitState{JobType: "postsubmit", BaseCommitSHA: "f1c7ca0b532141898f56c1843ae60ebec3a75a85"},
+			tags: []Tag{
+				{Name: "latest", Value: "latest", When: &Condition{Ref: `^refs/heads/main$`}},
+			},
+			expectedErr: true,
+		},
+		{
+			name:     "fail, invalid condition",
+			gitState: mainPostsubmit,
+			tags: []Tag{
+				{Name: "latest", Value: "latest", When: &Condition{Ref: `^refs/(heads`}},
+			},
+			expectedErr: true,
+		},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			tagger, err := NewTagger(zap.NewNop().Sugar(), c.tags, CommitSHA(c.gitState.BaseCommitSHA), WithGitState(c.gitState))
+			if err != nil {
+				t.Fatalf("error creating tagger: %v", err)
+			}
+			got, err := tagger.ParseTags()
+			if err != nil && !c.expectedErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err == nil && c.expectedErr {
+				t.Errorf("expected error, got nil")
+			}
+			if !reflect.DeepEqual(got, c.expected) {
+				t.Errorf("ParseTags() = %v, expected %v", got, c.expected)
+			}
+			var skipped []string
+			for _, s := range tagger.Skipped() {
+				if s.Reason == "" {
+					t.Errorf("skipped tag %s has no reason", s.Tag.Name)
+				}
+				skipped = append(skipped, s.Tag.Name)
+			}
+			if !reflect.DeepEqual(skipped, c.expSkipped) {
+				t.Errorf("Skipped() = %v, expected %v", skipped, c.expSkipped)
+			}
+		})
+	}
+}
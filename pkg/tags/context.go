@@ -0,0 +1,66 @@
+package tags
+
+import (
+	"regexp"
+	"strings"
+)
+
+// maxTagLength is the maximum length of the docker image tag.
+const maxTagLength = 128
+
+// tagInvalidChars matches characters not allowed in docker image tags.
+var tagInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// Sanitize converts the value into a valid docker tag component.
+// Sequences of characters not allowed in tags are replaced with "-", leading "." and "-" are removed,
+// trailing "-" are removed and the result is truncated to the maximum tag length.
+// For example, "feature/New Login" is converted to "feature-New-Login".
+func Sanitize(value string) string {
+	sanitized := tagInvalidChars.ReplaceAllString(value, "-")
+	sanitized = strings.TrimLeft(sanitized, ".-")
+	if len(sanitized) > maxTagLength {
+		sanitized = sanitized[:maxTagLength]
+	}
+	return strings.TrimRight(sanitized, "-")
+}
+
+// Component is a Tagger field value which is sanitized into a valid docker tag component when used in a template.
+// Comparing it in a template, e.g. {{ if eq .JobType "presubmit" }}, uses the raw value.
+type Component string
+
+// String returns the sanitized value.
+func (c Component) String() string {
+	return Sanitize(string(c))
+}
+
+// Components is a list of Tagger field values, e.g. platforms.
+// When used in a template, the values are sanitized and joined with "_".
+// For example, linux/amd64 and linux/arm64 are converted to "linux-amd64_linux-arm64".
+type Components []Component
+
+// String returns sanitized values joined with "_".
+func (c Components) String() string {
+	sanitized := make([]string, 0, len(c))
+	for _, component := range c {
+		sanitized = append(sanitized, component.String())
+	}
+	return strings.Join(sanitized, "_")
+}
+
+// GitState describes the git and CI context the image is built for.
+type GitState struct {
+	// RepositoryOwner is the name of the source repository's owner
+	RepositoryOwner string
+	// RepositoryName is the name of the source repository
+	RepositoryName string
+	// JobType is the type of the CI job, e.g. presubmit or postsubmit
+	JobType string
+	// BaseCommitSHA is the commit SHA of the base branch or tag
+	BaseCommitSHA string
+	// BaseCommitRef is the base branch or tag, e.g. refs/heads/main
+	BaseCommitRef string
+	// PullHeadCommitSHA is the commit SHA of the head of the pull request
+	PullHeadCommitSHA string
+	// TagName is the name of the git tag the job runs for
+	TagName string
+}
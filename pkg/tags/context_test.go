@@ -0,0 +1,29 @@
+package tags
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	tc := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{name: "valid value is not changed", value: "v1.2.3_rc-1", expected: "v1.2.3_rc-1"},
+		{name: "branch with slash", value: "feature/new-login", expected: "feature-new-login"},
+		{name: "sequence of invalid characters", value: "refs/heads/New Login!", expected: "refs-heads-New-Login"},
+		{name: "leading dots and dashes", value: ".-/hidden", expected: "hidden"},
+		{name: "too long value is truncated", value: strings.Repeat("a", 130), expected: strings.Repeat("a", 128)},
+		{name: "empty value", value: "", expected: ""},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			if got := Sanitize(c.value); got != c.expected {
+				t.Errorf("Sanitize(): Got %q, but expected %q", got, c.expected)
+			}
+		})
+	}
+}
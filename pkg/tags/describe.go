@@ -0,0 +1,174 @@
+package tags
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// describeAbbrevLength is the length of the abbreviated commit SHA in the describe output, the same as git uses by default.
+const describeAbbrevLength = 7
+
+// Describe holds git-describe style data of the HEAD commit of the local repository.
+// It's computed with a pure-Go git implementation, without network access, similar to git describe --tags --first-parent.
+type Describe struct {
+	// Tag is the nearest git tag reachable from HEAD following first parents, empty if none is found
+	Tag string
+	// Distance is the number of commits between the tag and HEAD.
+	// If no tag is found, it's the number of commits available in the history.
+	Distance int
+	// ShortSHA is the abbreviated HEAD commit SHA
+	ShortSHA string
+	// Dirty is true if the worktree has uncommitted changes
+	Dirty bool
+	// Shallow is true if the history is shallow and the walk stopped at a shallow commit.
+	// The nearest tag may be missing in such a history.
+	Shallow bool
+}
+
+// String returns the describe output sanitized into a valid docker tag component, e.g. v1.4.2-3-gabc1234 or v1.4.2-3-gabc1234-dirty.
+// If no tag is found, e.g. because the history is shallow, it returns the abbreviated commit SHA, e.g. gabc1234.
+func (d Describe) String() string {
+	var describe string
+	if d.Tag != "" {
+		describe = fmt.Sprintf("%s-%d-g%s", d.Tag, d.Distance, d.ShortSHA)
+	} else {
+		describe = "g" + d.ShortSHA
+	}
+	if d.Dirty {
+		describe += "-dirty"
+	}
+	return Sanitize(describe)
+}
+
+// describeRepository computes describe data of the HEAD commit of the repository at the path.
+// The repository is looked up in the path and its parent directories.
+func describeRepository(path string) (Describe, error) {
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return Describe{}, fmt.Errorf("failed opening git repository at %s: %w", path, err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return Describe{}, fmt.Errorf("failed reading HEAD of git repository: %w", err)
+	}
+
+	tagsByCommit, err := commitTags(repo)
+	if err != nil {
+		return Describe{}, err
+	}
+	shallow, err := repo.Storer.Shallow()
+	if err != nil {
+		return Describe{}, fmt.Errorf("failed reading shallow commits of git repository: %w", err)
+	}
+
+	d := Describe{ShortSHA: head.Hash().String()[:describeAbbrevLength]}
+	d.Tag, d.Distance, d.Shallow, err = nearestTag(repo, head.Hash(), tagsByCommit, shallow)
+	if err != nil {
+		return Describe{}, err
+	}
+
+	// Bare repositories have no worktree, so they can't be dirty
+	worktree, err := repo.Worktree()
+	if err == nil {
+		status, err := worktree.Status()
+		if err != nil {
+			return Describe{}, fmt.Errorf("failed reading status of git worktree: %w", err)
+		}
+		d.Dirty = !status.IsClean()
+	} else if !errors.Is(err, git.ErrIsBareRepository) {
+		return Describe{}, fmt.Errorf("failed opening git worktree: %w", err)
+	}
+	return d, nil
+}
+
+// nearestTag walks first parents from the commit and returns the first tag found with its distance.
+// The walk stops at shallow commits and missing commits, in that case shallow is true.
+func nearestTag(repo *git.Repository, from plumbing.Hash, tagsByCommit map[plumbing.Hash][]string, shallowCommits []plumbing.Hash) (tag string, distance int, shallow bool, err error) {
+	isShallow := make(map[plumbing.Hash]bool, len(shallowCommits))
+	for _, hash := range shallowCommits {
+		isShallow[hash] = true
+	}
+
+	hash := from
+	for {
+		if names, found := tagsByCommit[hash]; found {
+			return preferredTag(names), distance, false, nil
+		}
+		if isShallow[hash] {
+			return "", distance + 1, true, nil
+		}
+		commit, err := repo.CommitObject(hash)
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			// The commit is referenced by its child, but it's not in the history
+			return "", distance, true, nil
+		}
+		if err != nil {
+			return "", 0, false, fmt.Errorf("failed reading commit %s: %w", hash, err)
+		}
+		if commit.NumParents() == 0 {
+			return "", distance + 1, false, nil
+		}
+		hash = commit.ParentHashes[0]
+		distance++
+	}
+}
+
+// commitTags returns names of tags pointing to each commit. Annotated tags are peeled to their commits.
+func commitTags(repo *git.Repository) (map[plumbing.Hash][]string, error) {
+	refs, err := repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("failed listing tags of git repository: %w", err)
+	}
+	tagsByCommit := make(map[plumbing.Hash][]string)
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		hash := ref.Hash()
+		tagObject, err := repo.TagObject(hash)
+		switch {
+		case err == nil:
+			commit, err := tagObject.Commit()
+			if errors.Is(err, object.ErrUnsupportedObject) {
+				// Tags of trees and blobs can't be described
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed reading commit of tag %s: %w", ref.Name().Short(), err)
+			}
+			hash = commit.Hash
+		case !errors.Is(err, plumbing.ErrObjectNotFound):
+			return fmt.Errorf("failed reading tag %s: %w", ref.Name().Short(), err)
+		}
+		tagsByCommit[hash] = append(tagsByCommit[hash], ref.Name().Short())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tagsByCommit, nil
+}
+
+// preferredTag returns the highest semantic version from tags pointing to the same commit.
+// Tags which are not semantic versions are compared as strings and are preferred less.
+func preferredTag(names []string) string {
+	sorted := append([]string(nil), names...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		vi, erri := semver.NewVersion(sorted[i])
+		vj, errj := semver.NewVersion(sorted[j])
+		switch {
+		case erri == nil && errj == nil:
+			return vi.GreaterThan(vj)
+		case erri == nil:
+			return true
+		case errj == nil:
+			return false
+		default:
+			return sorted[i] > sorted[j]
+		}
+	})
+	return sorted[0]
+}
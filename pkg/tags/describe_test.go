@@ -0,0 +1,211 @@
+package tags
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"go.uber.org/zap"
+)
+
+// testRepository is a git repository in a temporary directory.
+type testRepository struct {
+	t    *testing.T
+	dir  string
+	repo *git.Repository
+}
+
+func newTestRepository(t *testing.T) *testRepository {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("error initializing git repository: %v", err)
+	}
+	return &testRepository{t: t, dir: dir, repo: repo}
+}
+
+// commit writes the file and commits it.
+func (r *testRepository) commit(content string) plumbing.Hash {
+	r.t.Helper()
+	r.write(content)
+	worktree, err := r.repo.Worktree()
+	if err != nil {
+		r.t.Fatalf("error opening worktree: %v", err)
+	}
+	if _, err := worktree.Add("file.txt"); err != nil {
+		r.t.Fatalf("error adding file: %v", err)
+	}
+	hash, err := worktree.Commit(content, &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+	if err != nil {
+		r.t.Fatalf("error committing: %v", err)
+	}
+	return hash
+}
+
+// write changes the file without committing it.
+func (r *testRepository) write(content string) {
+	r.t.Helper()
+	if err := os.WriteFile(filepath.Join(r.dir, "file.txt"), []byte(content), 0o644); err != nil {
+		r.t.Fatalf("error writing file: %v", err)
+	}
+}
+
+// tag creates the tag, the tag is annotated if the message is not empty.
+func (r *testRepository) tag(name string, hash plumbing.Hash, message string) {
+	r.t.Helper()
+	var opts *git.CreateTagOptions
+	if message != "" {
+		opts = &git.CreateTagOptions{Tagger: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}, Message: message}
+	}
+	if _, err := r.repo.CreateTag(name, hash, opts); err != nil {
+		r.t.Fatalf("error creating tag %s: %v", name, err)
+	}
+}
+
+func TestDescribeRepository(t *testing.T) {
+	tc := []struct {
+		name     string
+		setup    func(r *testRepository)
+		expected Describe
+		expStr   string
+	}{
+		{
+			name: "HEAD is tagged",
+			setup: func(r *testRepository) {
+				r.tag("v1.0.0", r.commit("first"), "")
+			},
+			expected: Describe{Tag: "v1.0.0"},
+			expStr:   "v1.0.0-0-g%s",
+		},
+		{
+			name: "commits since annotated tag",
+			setup: func(r *testRepository) {
+				r.tag("v1.4.2", r.commit("first"), "release v1.4.2")
+				r.commit("second")
+				r.commit("third")
+			},
+			expected: Describe{Tag: "v1.4.2", Distance: 2},
+			expStr:   "v1.4.2-2-g%s",
+		},
+		{
+			name: "highest version of tags on the same commit",
+			setup: func(r *testRepository) {
+				hash := r.commit("first")
+				r.tag("v1.9.0", hash, "")
+				r.tag("v1.10.0", hash, "")
+				r.tag("latest", hash, "")
+				r.commit("second")
+			},
+			expected: Describe{Tag: "v1.10.0", Distance: 1},
+			expStr:   "v1.10.0-1-g%s",
+		},
+		{
+			name: "dirty worktree",
+			setup: func(r *testRepository) {
+				r.tag("v1.0.0", r.commit("first"), "")
+				r.write("changed")
+			},
+			expected: Describe{Tag: "v1.0.0", Dirty: true},
+			expStr:   "v1.0.0-0-g%s-dirty",
+		},
+		{
+			name: "no tag",
+			setup: func(r *testRepository) {
+				r.commit("first")
+				r.commit("second")
+			},
+			expected: Describe{Distance: 2},
+			expStr:   "g%s",
+		},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			r := newTestRepository(t)
+			c.setup(r)
+			head, err := r.repo.Head()
+			if err != nil {
+				t.Fatalf("error reading HEAD: %v", err)
+			}
+			shortSHA := head.Hash().String()[:describeAbbrevLength]
+			c.expected.ShortSHA = shortSHA
+
+			// Describe from a subdirectory, like a build context in a repository
+			context := filepath.Join(r.dir, "context")
+			if err := os.Mkdir(context, 0o755); err != nil {
+				t.Fatalf("error creating context directory: %v", err)
+			}
+			got, err := describeRepository(context)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != c.expected {
+				t.Errorf("describeRepository() = %+v, expected %+v", got, c.expected)
+			}
+			if expStr := fmt.Sprintf(c.expStr, shortSHA); got.String() != expStr {
+				t.Errorf("Describe.String() = %s, expected %s", got.String(), expStr)
+			}
+		})
+	}
+}
+
+func TestNearestTag_shallow(t *testing.T) {
+	r := newTestRepository(t)
+	r.tag("v1.0.0", r.commit("first"), "")
+	second := r.commit("second")
+	head := r.commit("third")
+
+	tagsByCommit, err := commitTags(r.repo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The history is cut at the second commit, so the tag can't be reached
+	tag, distance, shallow, err := nearestTag(r.repo, head, tagsByCommit, []plumbing.Hash{second})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tag != "" || distance != 2 || !shallow {
+		t.Errorf("nearestTag() = %q, %d, %t, expected no tag, distance 2 and shallow history", tag, distance, shallow)
+	}
+}
+
+func TestTagger_Describe(t *testing.T) {
+	r := newTestRepository(t)
+	r.tag("v2.1.0", r.commit("first"), "")
+	r.commit("second")
+
+	tagger, err := NewTagger(zap.NewNop().Sugar(), []Tag{
+		{Name: "describe", Value: "{{ .Describe }}"},
+		{Name: "release", Value: `{{ with .Describe }}{{ .Tag }}-{{ .Distance }}{{ end }}`},
+	}, GitRepository(r.dir))
+	if err != nil {
+		t.Fatalf("error creating tagger: %v", err)
+	}
+	got, err := tagger.ParseTags()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d, err := tagger.Describe()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Tag{{Name: "describe", Value: d.String()}, {Name: "release", Value: "v2.1.0-1"}}
+	if len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] {
+		t.Errorf("ParseTags() = %v, expected %v", got, expected)
+	}
+}
+
+func TestTagger_Describe_without_repository(t *testing.T) {
+	tagger, err := NewTagger(zap.NewNop().Sugar(), []Tag{{Name: "describe", Value: "{{ .Describe }}"}})
+	if err != nil {
+		t.Fatalf("error creating tagger: %v", err)
+	}
+	if _, err := tagger.ParseTags(); err == nil {
+		t.Errorf("expected error, git repository is not set")
+	}
+}
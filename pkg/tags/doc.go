@@ -0,0 +1,19 @@
+// Package tags builds docker image tags from go-template tag definitions.
+//
+// Templates are executed with Tagger as data, so its fields, e.g. {{ .ShortSHA }} or {{ .Branch }},
+// and the Env method, e.g. {{ .Env "VARIABLE" }}, can be used in templates.
+// Fields of the Component type are sanitized into a valid docker tag component when used.
+// The Env method reads only environment variables allowed with the EnvAllowlist option.
+//
+// Templates can also use a curated set of functions for string manipulation, sanitizing values
+// into docker tag components, time formatting, semantic versions and default values.
+// For example:
+//
+//	{{ .Branch | lower | trunc 20 }}-{{ .ShortSHA }}
+//	{{ .Time | utc | date "20060102-1504" }}
+//	v{{ bumpMinor .Version }}
+//	{{ .PRNumber | default "main" }}
+//
+// See funcMap for the list of all available functions.
+// The same functions are available when tag definitions are validated with Tag.Validate.
+package tags
@@ -0,0 +1,54 @@
+package tags
+
+import (
+	"fmt"
+	"os"
+	"path"
+)
+
+// redactedValue replaces values of sensitive environment variables and tags using them in logs.
+const redactedValue = "[REDACTED]"
+
+// EnvPatterns is a list of glob patterns matching environment variable names, e.g. GITHUB_* or BUILD_VERSION.
+// Patterns use the path.Match syntax.
+type EnvPatterns []string
+
+// Validate returns an error if any of the patterns is malformed.
+func (p EnvPatterns) Validate() error {
+	for _, pattern := range p {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid environment variable pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Match reports whether the variable name matches any of the patterns.
+func (p EnvPatterns) Match(name string) bool {
+	for _, pattern := range p {
+		// Patterns are validated when they are set, so the error can be ignored
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Env returns the value of the environment variable, e.g. {{ .Env "BUILD_VERSION" }}.
+// Only variables allowed with the EnvAllowlist option can be read, reading other variables returns an error.
+// Values of variables marked with the SensitiveEnv option are never logged,
+// and values of tags using them are redacted in logs and errors.
+func (tg *Tagger) Env(key string) (string, error) {
+	if !tg.envAllowlist.Match(key) {
+		tg.logger.Debugw("denied reading environment variable not allowed in tag templates", "variable_name", key)
+		return "", fmt.Errorf("environment variable %s is not allowed in tag templates", key)
+	}
+	value := os.Getenv(key)
+	if tg.sensitiveEnv.Match(key) {
+		tg.sensitiveRead = true
+		tg.logger.Debugw("reading sensitive environment variable", "variable_name", key, "variable_value", redactedValue)
+		return value, nil
+	}
+	tg.logger.Debugw("reading environment variable", "variable_name", key, "variable_value", value)
+	return value, nil
+}
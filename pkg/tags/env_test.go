@@ -0,0 +1,86 @@
+package tags
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestEnvPatterns_Match(t *testing.T) {
+	patterns := EnvPatterns{"GITHUB_*", "BUILD_VERSION", "IMAGE_?"}
+	tc := []struct {
+		name     string
+		variable string
+		expected bool
+	}{
+		{name: "glob match", variable: "GITHUB_REF_NAME", expected: true},
+		{name: "exact match", variable: "BUILD_VERSION", expected: true},
+		{name: "single character match", variable: "IMAGE_A", expected: true},
+		{name: "no match", variable: "AZURE_CLIENT_SECRET", expected: false},
+		{name: "prefix of exact pattern doesn't match", variable: "BUILD_VERSION_OLD", expected: false},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			if got := patterns.Match(c.variable); got != c.expected {
+				t.Errorf("Match(%s) = %t, expected %t", c.variable, got, c.expected)
+			}
+		})
+	}
+}
+
+func TestEnvAllowlist_invalid_pattern(t *testing.T) {
+	if _, err := NewTagger(zap.NewNop().Sugar(), nil, EnvAllowlist("GITHUB_[")); err == nil {
+		t.Errorf("expected error for malformed pattern")
+	}
+	if _, err := NewTagger(zap.NewNop().Sugar(), nil, SensitiveEnv("[")); err == nil {
+		t.Errorf("expected error for malformed pattern")
+	}
+}
+
+func TestTagger_Env_sensitive_values_are_not_logged(t *testing.T) {
+	const secret = "s3cr3t-value"
+	t.Setenv("BUILD_TOKEN", secret)
+	t.Setenv("BUILD_VERSION", "1.2.3")
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(core).Sugar()
+	tagger, err := NewTagger(logger, []Tag{
+		{Name: "version", Value: `{{ .Env "BUILD_VERSION" }}`},
+		{Name: "token", Value: `{{ .Env "BUILD_TOKEN" }}`},
+		{Name: "invalid", Value: `{{ .Env "BUILD_TOKEN" }}`, Validation: `^v\d+$`},
+	}, WithLogger(logger), EnvAllowlist("BUILD_*"), SensitiveEnv("*_TOKEN"))
+	if err != nil {
+		t.Fatalf("error creating tagger: %v", err)
+	}
+
+	_, err = tagger.ParseTags()
+	if err == nil {
+		t.Fatalf("expected error, invalid tag doesn't match validation and has duplicated value")
+	}
+	if strings.Contains(err.Error(), secret) {
+		t.Errorf("error contains the sensitive value: %v", err)
+	}
+	var tagErr *TagError
+	if errors.As(err, &tagErr) && tagErr.Value != redactedValue {
+		t.Errorf("TagError.Value = %s, expected %s", tagErr.Value, redactedValue)
+	}
+
+	var versionLogged bool
+	for _, entry := range logs.All() {
+		logged := fmt.Sprint(entry.Message, entry.ContextMap())
+		if strings.Contains(logged, secret) {
+			t.Errorf("log entry contains the sensitive value: %s", logged)
+		}
+		if strings.Contains(logged, "1.2.3") {
+			versionLogged = true
+		}
+	}
+	if !versionLogged {
+		t.Errorf("expected value of not sensitive variable to be logged")
+	}
+}
@@ -0,0 +1,66 @@
+package tags
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// ociTagGrammar is the tag grammar of the OCI distribution specification.
+// See: https://github.com/opencontainers/distribution-spec/blob/main/spec.md#pulling-manifests
+var ociTagGrammar = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}$`)
+
+// Rule identifies the check a tag failed.
+type Rule string
+
+const (
+	// RuleNotEmpty requires the tag name and value to be set.
+	RuleNotEmpty Rule = "not-empty"
+	// RuleCondition requires the tag condition to be valid.
+	RuleCondition Rule = "condition"
+	// RuleTemplate requires the tag value to be a go-template which can be executed with Tagger fields.
+	RuleTemplate Rule = "template"
+	// RuleValidation requires the parsed tag value to match the tag validation regex.
+	RuleValidation Rule = "validation"
+	// RuleOCIGrammar requires the parsed tag value to match the OCI distribution tag grammar.
+	RuleOCIGrammar Rule = "oci-grammar"
+	// RuleUniqueName requires tag names to be unique.
+	RuleUniqueName Rule = "unique-name"
+	// RuleUniqueValue requires parsed tag values to be unique.
+	RuleUniqueValue Rule = "unique-value"
+)
+
+// TagError describes a tag which failed the rule.
+// ParseTags returns all found TagErrors as one aggregated error.
+type TagError struct {
+	// Tag is the tag definition, with the value as it was before parsing the template
+	Tag Tag
+	// Value is the parsed tag value, it's empty if the template wasn't executed
+	Value string
+	// Rule is the failed rule
+	Rule Rule
+	// Err is the cause of the failure
+	Err error
+}
+
+// Error returns the error message with the tag name, template and failed rule.
+func (e *TagError) Error() string {
+	return fmt.Sprintf("tag %s with template %q failed %s rule: %s", e.Tag.Name, e.Tag.Value, e.Rule, e.Err)
+}
+
+// Unwrap returns the cause of the failure.
+func (e *TagError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateOCITag returns an error if the value doesn't match the OCI distribution tag grammar.
+// The tag must start with a letter, digit or underscore, contain only letters, digits, underscores,
+// periods and dashes, and be at most 128 characters long.
+func ValidateOCITag(value string) error {
+	if len(value) > maxTagLength {
+		return fmt.Errorf("tag %s is %d characters long, at most %d characters are allowed", value, len(value), maxTagLength)
+	}
+	if !ociTagGrammar.MatchString(value) {
+		return fmt.Errorf("tag %q doesn't match the OCI tag grammar %s", value, ociTagGrammar.String())
+	}
+	return nil
+}
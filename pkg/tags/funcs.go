@@ -0,0 +1,140 @@
+package tags
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// funcMap returns functions available in tag templates.
+// Functions taking a value accept strings and Tagger fields, the value is always the last argument,
+// so functions can be chained with pipes, e.g. {{ .Branch | lower | trunc 20 }}.
+//
+// String functions:
+//   - lower VALUE, upper VALUE: converts the value to lower or upper case
+//   - trim VALUE: removes leading and trailing white spaces
+//   - trimPrefix PREFIX VALUE, trimSuffix SUFFIX VALUE: removes the prefix or suffix
+//   - replace OLD NEW VALUE: replaces all occurrences of OLD with NEW
+//   - trunc N VALUE: truncates the value to N characters
+//   - contains SUBSTR VALUE, hasPrefix PREFIX VALUE, hasSuffix SUFFIX VALUE: reports whether the value contains,
+//     starts or ends with the string, e.g. {{ if hasPrefix "release-" .Branch }}
+//
+// Docker tag functions:
+//   - sanitize VALUE: converts the value into a valid docker tag component, see Sanitize
+//
+// Time functions:
+//   - date LAYOUT TIME: formats the time with the go layout, e.g. {{ date "2006-01-02" .Time }}
+//   - utc TIME: converts the time to UTC, e.g. {{ .Time | utc | date "20060102-1504" }}
+//   - unix TIME: returns the time as unix seconds
+//
+// Semver functions:
+//   - semver VALUE: parses the semantic version, with or without the leading "v".
+//     The result has Major, Minor, Patch and Prerelease methods, e.g. {{ (semver .Version).Major }}
+//   - bumpMajor VALUE, bumpMinor VALUE, bumpPatch VALUE: returns the next major, minor or patch version without the leading "v"
+//   - semverCompare CONSTRAINT VALUE: reports whether the version satisfies the constraint, e.g. {{ if semverCompare ">=2.0.0" .Version }}
+//
+// Default functions:
+//   - default DEFAULT VALUE: returns the value or DEFAULT if the value is empty, e.g. {{ .PRNumber | default "none" }}
+//   - coalesce VALUES...: returns the first non-empty value
+func funcMap() template.FuncMap {
+	return template.FuncMap{
+		"lower":      func(value any) string { return strings.ToLower(toString(value)) },
+		"upper":      func(value any) string { return strings.ToUpper(toString(value)) },
+		"trim":       func(value any) string { return strings.TrimSpace(toString(value)) },
+		"trimPrefix": func(prefix string, value any) string { return strings.TrimPrefix(toString(value), prefix) },
+		"trimSuffix": func(suffix string, value any) string { return strings.TrimSuffix(toString(value), suffix) },
+		"replace":    func(old, new string, value any) string { return strings.ReplaceAll(toString(value), old, new) },
+		"trunc":      trunc,
+		"contains":   func(substr string, value any) bool { return strings.Contains(toString(value), substr) },
+		"hasPrefix":  func(prefix string, value any) bool { return strings.HasPrefix(toString(value), prefix) },
+		"hasSuffix":  func(suffix string, value any) bool { return strings.HasSuffix(toString(value), suffix) },
+
+		"sanitize": func(value any) string { return Sanitize(toString(value)) },
+
+		"date": func(layout string, t time.Time) string { return t.Format(layout) },
+		"utc":  func(t time.Time) time.Time { return t.UTC() },
+		"unix": func(t time.Time) int64 { return t.Unix() },
+
+		"semver":        parseSemver,
+		"bumpMajor":     func(value any) (string, error) { return bumpSemver(value, (*semver.Version).IncMajor) },
+		"bumpMinor":     func(value any) (string, error) { return bumpSemver(value, (*semver.Version).IncMinor) },
+		"bumpPatch":     func(value any) (string, error) { return bumpSemver(value, (*semver.Version).IncPatch) },
+		"semverCompare": semverCompare,
+
+		"default":  defaultValue,
+		"coalesce": coalesce,
+	}
+}
+
+// toString returns the value as a string. Tagger fields of the Component type are returned sanitized.
+func toString(value any) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// trunc truncates the value to n characters.
+func trunc(n int, value any) (string, error) {
+	if n < 0 {
+		return "", fmt.Errorf("trunc length can't be negative, got %d", n)
+	}
+	runes := []rune(toString(value))
+	if len(runes) <= n {
+		return string(runes), nil
+	}
+	return string(runes[:n]), nil
+}
+
+// parseSemver parses the semantic version. The leading "v" is optional.
+func parseSemver(value any) (*semver.Version, error) {
+	version, err := semver.NewVersion(toString(value))
+	if err != nil {
+		return nil, fmt.Errorf("invalid semantic version %q: %w", toString(value), err)
+	}
+	return version, nil
+}
+
+// bumpSemver returns the version incremented with the bump function, without the leading "v".
+func bumpSemver(value any, bump func(*semver.Version) semver.Version) (string, error) {
+	version, err := parseSemver(value)
+	if err != nil {
+		return "", err
+	}
+	bumped := bump(version)
+	return bumped.String(), nil
+}
+
+// semverCompare reports whether the version satisfies the constraint.
+func semverCompare(constraint string, value any) (bool, error) {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return false, fmt.Errorf("invalid semver constraint %q: %w", constraint, err)
+	}
+	version, err := parseSemver(value)
+	if err != nil {
+		return false, err
+	}
+	return c.Check(version), nil
+}
+
+// defaultValue returns the value or the default value if the value is empty.
+func defaultValue(def, value any) string {
+	if s := toString(value); s != "" {
+		return s
+	}
+	return toString(def)
+}
+
+// coalesce returns the first non-empty value.
+func coalesce(values ...any) string {
+	for _, value := range values {
+		if s := toString(value); s != "" {
+			return s
+		}
+	}
+	return ""
+}
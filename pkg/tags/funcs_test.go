@@ -0,0 +1,59 @@
+package tags
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func Test_funcMap(t *testing.T) {
+	tagger := &Tagger{
+		PRNumber: "",
+		Branch:   "Feature/New-Login",
+		Version:  "1.2.3-rc.1",
+		Time:     time.Date(2024, 4, 10, 9, 15, 0, 0, time.FixedZone("CEST", 2*60*60)),
+	}
+
+	tc := []struct {
+		name      string
+		template  string
+		expected  string
+		expectErr bool
+	}{
+		{name: "lower and trunc", template: `{{ .Branch | lower | trunc 11 }}`, expected: "feature-new"},
+		{name: "upper", template: `{{ upper "abc" }}`, expected: "ABC"},
+		{name: "trim prefix and suffix", template: `{{ "v1.0.0-dev" | trimPrefix "v" | trimSuffix "-dev" }}`, expected: "1.0.0"},
+		{name: "replace", template: `{{ replace "." "-" .Version }}`, expected: "1-2-3-rc-1"},
+		{name: "sanitize raw string", template: `{{ sanitize "refs/heads/main" }}`, expected: "refs-heads-main"},
+		{name: "hasPrefix condition", template: `{{ if hasPrefix "Feature" .Branch }}feature{{ else }}other{{ end }}`, expected: "feature"},
+		{name: "date with custom layout", template: `{{ .Time | utc | date "20060102-1504" }}`, expected: "20240410-0715"},
+		{name: "unix time", template: `{{ unix .Time }}`, expected: "1712733300"},
+		{name: "semver fields", template: `{{ with semver .Version }}{{ .Major }}.{{ .Minor }}-{{ .Prerelease }}{{ end }}`, expected: "1.2-rc.1"},
+		{name: "bump minor", template: `v{{ bumpMinor "v1.2.3" }}`, expected: "v1.3.0"},
+		{name: "semver compare", template: `{{ if semverCompare ">=1.0.0-0" .Version }}stable{{ end }}`, expected: "stable"},
+		{name: "default for empty value", template: `{{ .PRNumber | default "none" }}`, expected: "none"},
+		{name: "coalesce", template: `{{ coalesce .PRNumber "" .Branch }}`, expected: "Feature-New-Login"},
+		{name: "invalid semver, fail", template: `{{ bumpPatch "latest" }}`, expectErr: true},
+		{name: "negative trunc, fail", template: `{{ trunc -1 .Branch }}`, expectErr: true},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			tmpl, err := newTagTemplate(c.template)
+			if err != nil {
+				t.Fatalf("failed parsing template: %s", err)
+			}
+			buf := bytes.Buffer{}
+			err = tmpl.Execute(&buf, tagger)
+			if err != nil && !c.expectErr {
+				t.Errorf("got unexpected error: %s", err)
+			}
+			if err == nil && c.expectErr {
+				t.Error("error expected, but no one occured")
+			}
+			if err == nil && buf.String() != c.expected {
+				t.Errorf("got %q, but expected %q", buf.String(), c.expected)
+			}
+		})
+	}
+}
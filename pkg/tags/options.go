@@ -2,6 +2,7 @@ package tags
 
 import (
 	"fmt"
+	"strings"
 )
 
 type TagOption func(o *Tagger) error
@@ -44,6 +45,113 @@ func PRNumber(pr string) TagOption {
 	}
 }
 
+// GitTag sets Tagger GitTag field to the given value.
+// It also sets the Tagger Version field to the given value without the leading "v".
+// It returns an error if the given value is empty.
+func GitTag(name string) TagOption {
+	return func(t *Tagger) error {
+		if len(name) == 0 {
+			return fmt.Errorf("git tag cannot be empty")
+		}
+		t.GitTag = Component(name)
+		t.Version = Component(strings.TrimPrefix(name, "v"))
+		return nil
+	}
+}
+
+// WithGitState sets Tagger git context fields to values from the given git state.
+// The Tagger HeadSHA field is set to the pull request head commit SHA or, if it's empty, to the base commit SHA.
+// The Tagger Branch field is set to the base ref without the refs/heads/ prefix, it's empty for tags.
+// If the git state has the tag name, the Tagger GitTag and Version fields are set too.
+func WithGitState(state GitState) TagOption {
+	return func(t *Tagger) error {
+		t.RepoOwner = Component(state.RepositoryOwner)
+		t.RepoName = Component(state.RepositoryName)
+		t.JobType = Component(state.JobType)
+		t.BaseRef = Component(state.BaseCommitRef)
+		t.BaseSHA = state.BaseCommitSHA
+		t.HeadSHA = state.PullHeadCommitSHA
+		if len(t.HeadSHA) == 0 {
+			t.HeadSHA = state.BaseCommitSHA
+		}
+		if !strings.HasPrefix(state.BaseCommitRef, "refs/tags/") {
+			t.Branch = Component(strings.TrimPrefix(state.BaseCommitRef, "refs/heads/"))
+		}
+		if len(state.TagName) > 0 {
+			return GitTag(state.TagName)(t)
+		}
+		return nil
+	}
+}
+
+// ImageName sets Tagger ImageName field to the given value.
+// It returns an error if the given value is empty.
+func ImageName(name string) TagOption {
+	return func(t *Tagger) error {
+		if len(name) == 0 {
+			return fmt.Errorf("image name cannot be empty")
+		}
+		t.ImageName = Component(name)
+		return nil
+	}
+}
+
+// Platforms sets Tagger Platforms field to the given values.
+// It returns an error if no platform is given.
+func Platforms(platforms []string) TagOption {
+	return func(t *Tagger) error {
+		if len(platforms) == 0 {
+			return fmt.Errorf("platforms cannot be empty")
+		}
+		t.Platforms = make(Components, 0, len(platforms))
+		for _, platform := range platforms {
+			t.Platforms = append(t.Platforms, Component(platform))
+		}
+		return nil
+	}
+}
+
+// GitRepository sets the path of the local git repository used by the Tagger Describe method.
+// The repository is looked up in the path and its parent directories, so the path can be the build context.
+// It returns an error if the given value is empty.
+func GitRepository(path string) TagOption {
+	return func(t *Tagger) error {
+		if len(path) == 0 {
+			return fmt.Errorf("git repository path cannot be empty")
+		}
+		t.repoPath = path
+		return nil
+	}
+}
+
+// EnvAllowlist sets glob patterns of environment variables which can be read with the Tagger Env method.
+// Reading variables not matching any pattern returns an error. By default, no variables can be read.
+// It returns an error if any of the patterns is malformed.
+func EnvAllowlist(patterns ...string) TagOption {
+	return func(t *Tagger) error {
+		allowlist := EnvPatterns(patterns)
+		if err := allowlist.Validate(); err != nil {
+			return err
+		}
+		t.envAllowlist = allowlist
+		return nil
+	}
+}
+
+// SensitiveEnv sets glob patterns of environment variables whose values must not be logged.
+// Sensitive variables must also be allowed with EnvAllowlist to be read.
+// It returns an error if any of the patterns is malformed.
+func SensitiveEnv(patterns ...string) TagOption {
+	return func(t *Tagger) error {
+		sensitive := EnvPatterns(patterns)
+		if err := sensitive.Validate(); err != nil {
+			return err
+		}
+		t.sensitiveEnv = sensitive
+		return nil
+	}
+}
+
 func WithLogger(logger Logger) TagOption {
 	return func(t *Tagger) error {
 		t.logger = logger.With("component", "tagger")
@@ -114,3 +114,91 @@ func TestOption_DateFormat_return_error_when_empty_date_format(t *testing.T) {
 	g.Expect(err).To(HaveOccurred())
 	g.Expect(err.Error()).To(Equal("date format cannot be empty"))
 }
+
+func TestOption_GitTag_success(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tag := Tagger{}
+	f := GitTag("v1.2.3-rc.1")
+	err := f(&tag)
+
+	g.Expect(err).To(BeNil())
+	g.Expect(tag.GitTag).To(Equal(Component("v1.2.3-rc.1")))
+	g.Expect(tag.Version).To(Equal(Component("1.2.3-rc.1")))
+}
+
+func TestOption_GitTag_return_error_when_empty_tag(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tag := Tagger{}
+	f := GitTag("")
+	err := f(&tag)
+
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("git tag cannot be empty"))
+}
+
+func TestOption_WithGitState_success(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tag := Tagger{}
+	f := WithGitState(GitState{
+		RepositoryOwner:   "kyma-project",
+		RepositoryName:    "test-infra",
+		JobType:           "presubmit",
+		BaseCommitSHA:     "4321",
+		BaseCommitRef:     "refs/heads/release-1.2",
+		PullHeadCommitSHA: "1234",
+	})
+	err := f(&tag)
+
+	g.Expect(err).To(BeNil())
+	g.Expect(tag.RepoOwner).To(Equal(Component("kyma-project")))
+	g.Expect(tag.RepoName).To(Equal(Component("test-infra")))
+	g.Expect(tag.JobType).To(Equal(Component("presubmit")))
+	g.Expect(tag.BaseRef).To(Equal(Component("refs/heads/release-1.2")))
+	g.Expect(tag.Branch).To(Equal(Component("release-1.2")))
+	g.Expect(tag.BaseSHA).To(Equal("4321"))
+	g.Expect(tag.HeadSHA).To(Equal("1234"))
+	g.Expect(tag.GitTag).To(BeEmpty())
+}
+
+func TestOption_WithGitState_tag(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tag := Tagger{}
+	f := WithGitState(GitState{
+		JobType:       "postsubmit",
+		BaseCommitSHA: "4321",
+		BaseCommitRef: "refs/tags/v1.2.3",
+		TagName:       "v1.2.3",
+	})
+	err := f(&tag)
+
+	g.Expect(err).To(BeNil())
+	g.Expect(tag.Branch).To(BeEmpty())
+	g.Expect(tag.HeadSHA).To(Equal("4321"))
+	g.Expect(tag.GitTag).To(Equal(Component("v1.2.3")))
+	g.Expect(tag.Version).To(Equal(Component("1.2.3")))
+}
+
+func TestOption_ImageName_return_error_when_empty_name(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tag := Tagger{}
+	err := ImageName("")(&tag)
+
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("image name cannot be empty"))
+}
+
+func TestOption_Platforms_success(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tag := Tagger{}
+	err := Platforms([]string{"linux/amd64", "linux/arm64"})(&tag)
+
+	g.Expect(err).To(BeNil())
+	g.Expect(tag.Platforms).To(Equal(Components{"linux/amd64", "linux/arm64"}))
+	g.Expect(tag.Platforms.String()).To(Equal("linux-amd64_linux-arm64"))
+}
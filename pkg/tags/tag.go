@@ -1,9 +1,14 @@
 package tags
 
 import (
+	"bytes"
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	errutil "k8s.io/apimachinery/pkg/util/errors"
 )
 
 // Tag store informations about single Tag
@@ -14,6 +19,9 @@ type Tag struct {
 	Value string `yaml:"value" json:"value"`
 	// Validation is a regex pattern to validate the tag value after it has been parsed
 	Validation string `yaml:"validation" json:"validation,omitempty"`
+	// When is an optional condition the git context must meet for the tag to be used.
+	// Tags whose condition isn't met are skipped by ParseTags.
+	When *Condition `yaml:"when,omitempty" json:"when,omitempty"`
 }
 
 // NewTagFromString creates new Tag from env var style string
@@ -37,3 +45,66 @@ func NewTagFromString(val string) (Tag, error) {
 
 	return t, nil
 }
+
+// Validate checks the tag definition without parsing it for a real commit or pull request.
+// It verifies the name and value are set, the value is a valid template
+// which can be executed with Tagger fields, and the validation and the condition are valid.
+// All found problems are returned as one aggregated error.
+func (t Tag) Validate() error {
+	var errs []error
+	if len(t.Name) == 0 || len(t.Value) == 0 {
+		errs = append(errs, fmt.Errorf("tag name or value is empty, tag name: %s, tag value: %s", t.Name, t.Value))
+	}
+
+	tmpl, err := newTagTemplate(t.Value)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("error parsing tag template: %w", err))
+	} else if err := tmpl.Execute(&bytes.Buffer{}, sampleTagger()); err != nil {
+		errs = append(errs, fmt.Errorf("error executing tag template: %w", err))
+	}
+
+	// Values of templates depend on the build, so only literal values can be checked against the tag grammar
+	if len(t.Value) > 0 && !strings.Contains(t.Value, "{{") {
+		if err := ValidateOCITag(t.Value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if _, err := compileValidation(t); err != nil {
+		errs = append(errs, err)
+	}
+	if t.When != nil {
+		if err := t.When.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errutil.NewAggregate(errs)
+}
+
+// sampleTagger returns Tagger with all fields set to example values.
+// It's used to check tag templates can be executed.
+func sampleTagger() *Tagger {
+	now := time.Now()
+	return &Tagger{
+		logger:    zap.NewNop().Sugar(),
+		CommitSHA: "0123456789abcdef0123456789abcdef01234567",
+		ShortSHA:  "01234567",
+		PRNumber:  "1",
+		GitTag:    "v1.0.0",
+		Version:   "1.0.0",
+		Time:      now,
+		Date:      now.Format("20060102"),
+		RepoOwner: "kyma-project",
+		RepoName:  "test-infra",
+		JobType:   "postsubmit",
+		BaseRef:   "refs/heads/main",
+		Branch:    "main",
+		BaseSHA:   "0123456789abcdef0123456789abcdef01234567",
+		HeadSHA:   "0123456789abcdef0123456789abcdef01234567",
+		ImageName: "image",
+		Platforms: Components{"linux/amd64", "linux/arm64"},
+		describe:  &Describe{Tag: "v1.0.0", Distance: 1, ShortSHA: "0123456"},
+		// The allowlist is known only when tags are parsed, so templates can read any variable when they are validated
+		envAllowlist: EnvPatterns{"*"},
+	}
+}
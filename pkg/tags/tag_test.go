@@ -70,3 +70,76 @@ func TestNewTagFromString(t *testing.T) {
 		})
 	}
 }
+
+func TestTag_Validate(t *testing.T) {
+	tc := []struct {
+		Name      string
+		Tag       Tag
+		ExpectErr bool
+	}{
+		{
+			Name: "valid template and validation, pass",
+			Tag:  Tag{Name: "default_tag", Value: `v{{ .Date }}-{{ .ShortSHA }}-{{ .Env "TEST" }}`, Validation: `^v\d+-\w+-$`},
+		},
+		{
+			Name: "template functions, pass",
+			Tag:  Tag{Name: "Test", Value: `{{ .Branch | lower | trunc 20 }}-{{ bumpPatch .Version }}-{{ .PRNumber | default "main" }}`},
+		},
+		{
+			Name:      "invalid condition, fail",
+			Tag:       Tag{Name: "latest", Value: "latest", When: &Condition{Repository: "kyma-project/["}},
+			ExpectErr: true,
+		},
+		{
+			Name: "valid condition, pass",
+			Tag:  Tag{Name: "latest", Value: "latest", When: &Condition{JobTypes: []string{"postsubmit"}, Ref: `^refs/heads/main$`, Repository: "kyma-project/*"}},
+		},
+		{
+			Name:      "unknown template function, fail",
+			Tag:       Tag{Name: "Test", Value: `{{ .Branch | kebab }}`},
+			ExpectErr: true,
+		},
+		{
+			Name:      "empty value, fail",
+			Tag:       Tag{Name: "Test"},
+			ExpectErr: true,
+		},
+		{
+			Name:      "malformed template, fail",
+			Tag:       Tag{Name: "Test", Value: "{{ ..Date }}"},
+			ExpectErr: true,
+		},
+		{
+			Name:      "missing tagger field, fail",
+			Tag:       Tag{Name: "Test", Value: "{{ .Missing }}"},
+			ExpectErr: true,
+		},
+		{
+			Name:      "literal value not matching tag grammar, fail",
+			Tag:       Tag{Name: "Test", Value: "release/latest"},
+			ExpectErr: true,
+		},
+		{
+			Name:      "invalid validation regex, fail",
+			Tag:       Tag{Name: "Test", Value: "latest", Validation: `^(latest$`},
+			ExpectErr: true,
+		},
+		{
+			Name:      "default tag without validation, fail",
+			Tag:       Tag{Name: "default_tag", Value: "latest"},
+			ExpectErr: true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.Name, func(t *testing.T) {
+			err := c.Tag.Validate()
+			if err != nil && !c.ExpectErr {
+				t.Errorf("got error when no expect one: %v", err)
+			}
+			if err == nil && c.ExpectErr {
+				t.Error("expected error, but got none")
+			}
+		})
+	}
+}
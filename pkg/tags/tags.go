@@ -3,13 +3,13 @@ package tags
 import (
 	"bytes"
 	"fmt"
-	"os"
 	"regexp"
 	"text/template"
 	"time"
 
 	"github.com/kyma-project/test-infra/pkg/logging"
 	"go.uber.org/zap"
+	errutil "k8s.io/apimachinery/pkg/util/errors"
 )
 
 type Logger interface {
@@ -23,69 +23,191 @@ type Tagger struct {
 	CommitSHA string
 	ShortSHA  string
 	PRNumber  string
+	GitTag    Component
+	Version   Component
 	Time      time.Time
 	Date      string
+	// Git and CI context, see WithGitState
+	RepoOwner Component
+	RepoName  Component
+	JobType   Component
+	BaseRef   Component
+	Branch    Component
+	BaseSHA   string
+	HeadSHA   string
+	// Image context, see ImageName and Platforms
+	ImageName Component
+	Platforms Components
+	// repoPath is the path of the local git repository used by Describe, see GitRepository
+	repoPath string
+	// describe caches the result of Describe
+	describe    *Describe
+	describeErr error
+	// envAllowlist and sensitiveEnv control reading environment variables, see Env
+	envAllowlist EnvPatterns
+	sensitiveEnv EnvPatterns
+	// sensitiveRead is set when the executed template reads a sensitive environment variable
+	sensitiveRead bool
+	// skipped are tags skipped by the last ParseTags call, see Skipped
+	skipped []SkippedTag
 }
 
-func (tg *Tagger) Env(key string) string {
-	tg.logger.Debugw("reading environment variable", "variable_name", key, "variable_value", os.Getenv(key))
-	return os.Getenv(key)
+// Describe returns git-describe style data of the local repository set with the GitRepository option,
+// e.g. {{ .Describe }} or {{ .Describe.Tag }}.
+// It's computed when a template uses it for the first time, so repositories are not read if no template needs it.
+func (tg *Tagger) Describe() (Describe, error) {
+	if tg.describe == nil && tg.describeErr == nil {
+		if tg.repoPath == "" {
+			tg.describeErr = fmt.Errorf("git repository is not set, describe data is not available")
+		} else {
+			d, err := describeRepository(tg.repoPath)
+			tg.describe, tg.describeErr = &d, err
+			tg.logger.Debugw("described git repository", "path", tg.repoPath, "describe", d, "error", err)
+		}
+	}
+	if tg.describeErr != nil {
+		return Describe{}, tg.describeErr
+	}
+	return *tg.describe, nil
 }
 
+// ParseTags executes tag templates and validates parsed tags.
+// Each tag must match its validation regex and the OCI distribution tag grammar,
+// and names and parsed values of all tags must be unique.
+// All problems are returned at once as an aggregated error of TagErrors.
+// Values of tags reading sensitive environment variables are redacted in logs and errors.
+// Tags whose condition isn't met are skipped, they are not validated and are reported by Skipped.
 func (tg *Tagger) ParseTags() ([]Tag, error) {
 	tg.logger.Debugw("started parsing tags")
-	var parsed []Tag
+	tg.skipped = nil
+	var (
+		parsed []Tag
+		errs   []error
+		// shownTags are parsed tags with redacted sensitive values, used in logs
+		shownTags []Tag
+	)
+	names := make(map[string]bool)
+	values := make(map[string]string)
 	for _, tag := range tg.tags {
 		if len(tag.Name) == 0 || len(tag.Value) == 0 {
-			return nil, fmt.Errorf("tag name or value is empty, tag name: %s, tag value: %s", tag.Name, tag.Value)
+			errs = append(errs, &TagError{Tag: tag, Rule: RuleNotEmpty, Err: fmt.Errorf("tag name or value is empty, tag name: %s, tag value: %s", tag.Name, tag.Value)})
+			continue
 		}
 		logger := tg.logger.With("tag", tag.Name, "value", tag.Value)
 		logger.Debugw("verified tag name and value are not empty")
+		if tag.When != nil {
+			reason, err := tag.When.evaluate(tg)
+			if err != nil {
+				errs = append(errs, &TagError{Tag: tag, Rule: RuleCondition, Err: err})
+				continue
+			}
+			if reason != "" {
+				logger.Debugw("skipped tag, condition is not met", "reason", reason)
+				tg.skipped = append(tg.skipped, SkippedTag{Tag: tag, Reason: reason})
+				continue
+			}
+			logger.Debugw("tag condition is met")
+		}
+		if names[tag.Name] {
+			errs = append(errs, &TagError{Tag: tag, Rule: RuleUniqueName, Err: fmt.Errorf("tag name %s is used by more than one tag", tag.Name)})
+		}
+		names[tag.Name] = true
 		logger.Debugw("parsing tag template")
-		tmpl, err := template.New("tag").Parse(tag.Value)
+		tmpl, err := newTagTemplate(tag.Value)
 		if err != nil {
-			return nil, fmt.Errorf("error parsing tag template: %w", err)
+			errs = append(errs, &TagError{Tag: tag, Rule: RuleTemplate, Err: fmt.Errorf("error parsing tag template: %w", err)})
+			continue
 		}
 		logger.Debugw("parsed tag template")
 		buf := bytes.Buffer{}
+		tg.sensitiveRead = false
 		err = tmpl.Execute(&buf, tg)
 		if err != nil {
-			return nil, fmt.Errorf("error executing tag template: %w", err)
+			errs = append(errs, &TagError{Tag: tag, Rule: RuleTemplate, Err: fmt.Errorf("error executing tag template: %w", err)})
+			continue
 		}
-		logger.Debugw("successfully executed tag template", "computed_name", tag.Name, "computed_value", buf.String())
-		tag.Value = buf.String()
-		err = tg.validateTag(tag)
-		if err != nil {
-			return nil, fmt.Errorf("failed to validate tag: %w", err)
+		value := buf.String()
+		// shown is the value used in logs and errors
+		shown := value
+		if tg.sensitiveRead {
+			shown = redactedValue
 		}
-		logger.Debugw("tag validation passed")
-		parsed = append(parsed, tag)
+		logger.Debugw("successfully executed tag template", "computed_name", tag.Name, "computed_value", shown)
+		parsedTag := Tag{Name: tag.Name, Value: value, Validation: tag.Validation}
+		if err := tg.validateTag(parsedTag, shown); err != nil {
+			errs = append(errs, &TagError{Tag: tag, Value: shown, Rule: RuleValidation, Err: fmt.Errorf("failed to validate tag: %w", err)})
+		}
+		if err := ValidateOCITag(value); err != nil {
+			if tg.sensitiveRead {
+				err = fmt.Errorf("tag value doesn't match the OCI tag grammar %s", ociTagGrammar.String())
+			}
+			errs = append(errs, &TagError{Tag: tag, Value: shown, Rule: RuleOCIGrammar, Err: err})
+		}
+		if name, found := values[value]; found {
+			errs = append(errs, &TagError{Tag: tag, Value: shown, Rule: RuleUniqueValue, Err: fmt.Errorf("tag value %s is also the value of tag %s", shown, name)})
+		}
+		values[value] = tag.Name
+		logger.Debugw("tag validation finished")
+		parsed = append(parsed, parsedTag)
+		shownTags = append(shownTags, Tag{Name: tag.Name, Value: shown, Validation: tag.Validation})
 		logger.Debugw("added tag to parsed tags")
 	}
-	tg.logger.Debugw("all tags parsed", "parsed_tags", parsed)
+	if len(errs) > 0 {
+		return nil, errutil.NewAggregate(errs)
+	}
+	tg.logger.Debugw("all tags parsed", "parsed_tags", shownTags)
 
 	return parsed, nil
 }
 
-func (tg *Tagger) validateTag(tag Tag) error {
-	logger := tg.logger.With("tag", tag.Name, "value", tag.Value, "validation", tag.Validation)
+// Skipped returns tags skipped by the last ParseTags call, because their condition isn't met.
+func (tg *Tagger) Skipped() []SkippedTag {
+	return tg.skipped
+}
+
+// validateTag checks the parsed tag matches its validation regex. The shown value is used in logs and errors instead of the tag value.
+func (tg *Tagger) validateTag(tag Tag, shown string) error {
+	logger := tg.logger.With("tag", tag.Name, "value", shown, "validation", tag.Validation)
 	logger.Debugw("started validating tag")
 	logger.Debugw("checking if validation regex is provided")
-	if tag.Name == "default_tag" && len(tag.Validation) == 0 {
-		return fmt.Errorf("default_tag required validation is empty, tag: %s", tag.Value)
+	re, err := compileValidation(tag)
+	if err != nil {
+		return err
 	}
-	if tag.Validation != "" {
-		re := regexp.MustCompile(tag.Validation)
+	if re != nil {
 		logger.Debugw("compiled regex", "regex", re.String())
 		match := re.FindAllString(tag.Value, -1)
 		if match == nil {
-			return fmt.Errorf("no regex match found, tag: %s, validation: %s", tag.Value, tag.Validation)
+			return fmt.Errorf("no regex match found, tag: %s, validation: %s", shown, tag.Validation)
 		}
-		logger.Debugw("regex matched successfully", "match", match)
+		logger.Debugw("regex matched successfully")
 	}
 	return nil
 }
 
+// newTagTemplate parses the tag value as a go-template executed with Tagger as data.
+// Functions from funcMap are available in the template.
+func newTagTemplate(value string) (*template.Template, error) {
+	return template.New("tag").Funcs(funcMap()).Parse(value)
+}
+
+// compileValidation compiles the validation regex of the tag.
+// It returns nil regex if the tag has no validation.
+// The default_tag is required to have a validation.
+func compileValidation(tag Tag) (*regexp.Regexp, error) {
+	if tag.Name == "default_tag" && len(tag.Validation) == 0 {
+		return nil, fmt.Errorf("default_tag required validation is empty, tag: %s", tag.Value)
+	}
+	if tag.Validation == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(tag.Validation)
+	if err != nil {
+		return nil, fmt.Errorf("invalid validation regex, tag: %s, validation: %s: %w", tag.Name, tag.Validation, err)
+	}
+	return re, nil
+}
+
 func NewTagger(logger Logger, tags []Tag, opts ...TagOption) (*Tagger, error) {
 	logger.Debugw("started creating new tagger", "tags", tags)
 	now := time.Now()
@@ -1,10 +1,14 @@
 package tags
 
 import (
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"go.uber.org/zap"
+	errutil "k8s.io/apimachinery/pkg/util/errors"
 )
 
 func TestTagger_ParseTags(t *testing.T) {
@@ -36,6 +40,23 @@ func TestTagger_ParseTags(t *testing.T) {
 			template: []Tag{{Name: "Test", Value: `v{{ .Date }}-{{ .Env "test-var" }}`}},
 			expected: Tag{Name: "Test", Value: "v20220602-test"},
 		},
+		{
+			name:     "fail, env variable not in allowlist",
+			template: []Tag{{Name: "Test", Value: `v{{ .Date }}-{{ .Env "HOME" }}`}},
+			expected: Tag{},
+			expErr:   true,
+		},
+		{
+			name:     "tag from git context is sanitized",
+			template: []Tag{{Name: "Branch", Value: `{{ .Branch }}-{{ if eq .JobType "postsubmit" }}{{ .ImageName }}{{ end }}`}},
+			expected: Tag{Name: "Branch", Value: "feature-new-login-tools-image"},
+		},
+		{
+			name:     "fail, invalid validation regex",
+			template: []Tag{{Name: "Test", Value: `v{{ .Date }}`, Validation: `^v(\d+$`}},
+			expected: Tag{},
+			expErr:   true,
+		},
 	}
 	for _, c := range tc {
 		t.Run(c.name, func(t *testing.T) {
@@ -51,7 +72,12 @@ func TestTagger_ParseTags(t *testing.T) {
 				CommitSHA: "f1c7ca0b532141898f56c1843ae60ebec3a75a85",
 				Time:      time.Now(),
 				Date:      time.Date(2022, 06, 02, 01, 01, 01, 1, time.Local).Format("20060102"),
+				JobType:   "postsubmit",
+				Branch:    "feature/new-login",
+				ImageName: "tools/image",
 				logger:    logger,
+				// test-var is allowed, other variables can't be read
+				envAllowlist: EnvPatterns{"test-*"},
 			}
 			got, err := tag.ParseTags()
 			if err != nil {
@@ -68,3 +94,46 @@ func TestTagger_ParseTags(t *testing.T) {
 		})
 	}
 }
+
+func TestTagger_ParseTags_reports_all_problems(t *testing.T) {
+	tagger := Tagger{
+		tags: []Tag{
+			{Name: "slash", Value: "release/{{ .ShortSHA }}"},
+			{Name: "leading-dot", Value: ".hidden"},
+			{Name: "too-long", Value: strings.Repeat("a", 129)},
+			{Name: "default_tag", Value: "v{{ .ShortSHA }}", Validation: "^PR-[0-9]+$"},
+			{Name: "sha", Value: "{{ .ShortSHA }}"},
+			{Name: "sha", Value: "sha-{{ .ShortSHA }}"},
+			{Name: "commit", Value: "{{ .ShortSHA }}"},
+			{Name: "valid", Value: "latest"},
+		},
+		ShortSHA: "abc1234",
+		logger:   zap.NewNop().Sugar(),
+	}
+
+	_, err := tagger.ParseTags()
+
+	agg, ok := err.(errutil.Aggregate)
+	if !ok {
+		t.Fatalf("expected aggregated error, got %v", err)
+	}
+	var got []string
+	for _, e := range agg.Errors() {
+		var tagErr *TagError
+		if !errors.As(e, &tagErr) {
+			t.Fatalf("expected TagError, got %v", e)
+		}
+		got = append(got, tagErr.Tag.Name+":"+string(tagErr.Rule))
+	}
+	expected := []string{
+		"slash:oci-grammar",
+		"leading-dot:oci-grammar",
+		"too-long:oci-grammar",
+		"default_tag:validation",
+		"sha:unique-name",
+		"commit:unique-value",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got errors %v, but expected %v", got, expected)
+	}
+}